@@ -0,0 +1,270 @@
+package baidu
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
+	"github.com/comqositi/toolkits/thirdsdk/logx"
+)
+
+// Endpoint 百度文字识别接口
+type Endpoint string
+
+const (
+	// GeneralBasic 通用文字识别（标准版）
+	GeneralBasic Endpoint = "general_basic"
+	// AccurateBasic 通用文字识别（高精度版）
+	AccurateBasic Endpoint = "accurate_basic"
+	// General 通用文字识别（标准含位置版）
+	General Endpoint = "general"
+	// Accurate 通用文字识别（高精度含位置版）
+	Accurate Endpoint = "accurate"
+	// Handwriting 手写文字识别
+	Handwriting Endpoint = "handwriting"
+	// Table 表格文字识别
+	Table Endpoint = "table"
+	// IdCard 身份证识别
+	IdCard Endpoint = "idcard"
+	// BankCard 银行卡识别
+	BankCard Endpoint = "bankcard"
+	// BusinessLicense 营业执照识别
+	BusinessLicense Endpoint = "business_license"
+	// VatInvoice 增值税发票识别
+	VatInvoice Endpoint = "vat_invoice"
+	// TrainTicket 火车票识别
+	TrainTicket Endpoint = "train_ticket"
+	// Receipt 通用票据识别
+	Receipt Endpoint = "receipt"
+)
+
+// textEndpoint 是否为通用文字识别类接口，只有这类接口支持 Options 中的参数
+func (e Endpoint) textEndpoint() bool {
+	switch e {
+	case GeneralBasic, AccurateBasic, General, Accurate, Handwriting:
+		return true
+	}
+	return false
+}
+
+// Image 待识别的图片，FilePath、Url 二选一；PdfPage 大于 0 时 FilePath 为 pdf 文件，识别其中第 PdfPage 页
+type Image struct {
+	FilePath string
+	Url      string
+	PdfPage  int
+}
+
+// Recognize 调用指定接口识别图片，params 为接口的其他参数，result 为对应的响应结构体指针
+func (b *BaiduOcr) Recognize(endpoint Endpoint, image Image, params url.Values, result interface{}) error {
+	return b.RecognizeCtx(context.Background(), endpoint, image, params, result)
+}
+
+// RecognizeCtx 同 Recognize，ctx 取消时中止请求
+func (b *BaiduOcr) RecognizeCtx(ctx context.Context, endpoint Endpoint, image Image, params url.Values, result interface{}) error {
+	form := url.Values{}
+	if endpoint.textEndpoint() {
+		form = b.options.values()
+	}
+	for k, v := range params {
+		form[k] = v
+	}
+
+	switch {
+	case image.FilePath != "":
+		encode := b.getFileContentAsBase64(image.FilePath)
+		if encode == "" {
+			return errorx.ErrRead
+		}
+		if len(encode)/1024/1024 > 8 {
+			return errImageTooLarge
+		}
+		if image.PdfPage > 0 {
+			form.Set("pdf_file", encode)
+			form.Set("pdf_file_num", strconv.Itoa(image.PdfPage))
+		} else {
+			form.Set("image", encode)
+		}
+	case image.Url != "":
+		if len(image.Url) > 1024 {
+			return errUrlTooLong
+		}
+		form.Set("url", image.Url)
+	default:
+		return errorx.ErrEmptyInput
+	}
+
+	body, err := b.post(ctx, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+
+	var errRes ErrorResponse
+	if err := json.Unmarshal(body, &errRes); err != nil {
+		return invalidResponse(err)
+	}
+	if err := errRes.apiError(); err != nil {
+		logx.Or(b.logger).Warn("baidu recognize failed", "endpoint", endpoint, "err", err)
+		return err
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		return invalidResponse(err)
+	}
+	return nil
+}
+
+// GeneralBasic 通用文字识别（标准版）
+func (b *BaiduOcr) GeneralBasic(image Image) (*WordsResponse, error) {
+	return b.GeneralBasicCtx(context.Background(), image)
+}
+
+// GeneralBasicCtx 同 GeneralBasic，ctx 取消时中止请求
+func (b *BaiduOcr) GeneralBasicCtx(ctx context.Context, image Image) (*WordsResponse, error) {
+	res := &WordsResponse{}
+	return res, b.RecognizeCtx(ctx, GeneralBasic, image, nil, res)
+}
+
+// AccurateBasic 通用文字识别（高精度版）
+func (b *BaiduOcr) AccurateBasic(image Image) (*WordsResponse, error) {
+	return b.AccurateBasicCtx(context.Background(), image)
+}
+
+// AccurateBasicCtx 同 AccurateBasic，ctx 取消时中止请求
+func (b *BaiduOcr) AccurateBasicCtx(ctx context.Context, image Image) (*WordsResponse, error) {
+	res := &WordsResponse{}
+	return res, b.RecognizeCtx(ctx, AccurateBasic, image, nil, res)
+}
+
+// General 通用文字识别（标准含位置版）
+func (b *BaiduOcr) General(image Image) (*LocationResponse, error) {
+	return b.GeneralCtx(context.Background(), image)
+}
+
+// GeneralCtx 同 General，ctx 取消时中止请求
+func (b *BaiduOcr) GeneralCtx(ctx context.Context, image Image) (*LocationResponse, error) {
+	res := &LocationResponse{}
+	return res, b.RecognizeCtx(ctx, General, image, nil, res)
+}
+
+// Accurate 通用文字识别（高精度含位置版）
+func (b *BaiduOcr) Accurate(image Image) (*LocationResponse, error) {
+	return b.AccurateCtx(context.Background(), image)
+}
+
+// AccurateCtx 同 Accurate，ctx 取消时中止请求
+func (b *BaiduOcr) AccurateCtx(ctx context.Context, image Image) (*LocationResponse, error) {
+	res := &LocationResponse{}
+	return res, b.RecognizeCtx(ctx, Accurate, image, nil, res)
+}
+
+// Handwriting 手写文字识别
+func (b *BaiduOcr) Handwriting(image Image) (*LocationResponse, error) {
+	return b.HandwritingCtx(context.Background(), image)
+}
+
+// HandwritingCtx 同 Handwriting，ctx 取消时中止请求
+func (b *BaiduOcr) HandwritingCtx(ctx context.Context, image Image) (*LocationResponse, error) {
+	res := &LocationResponse{}
+	return res, b.RecognizeCtx(ctx, Handwriting, image, nil, res)
+}
+
+// Table 表格文字识别
+func (b *BaiduOcr) Table(image Image) (*TableResponse, error) {
+	return b.TableCtx(context.Background(), image)
+}
+
+// TableCtx 同 Table，ctx 取消时中止请求
+func (b *BaiduOcr) TableCtx(ctx context.Context, image Image) (*TableResponse, error) {
+	res := &TableResponse{}
+	return res, b.RecognizeCtx(ctx, Table, image, nil, res)
+}
+
+// IdCard 身份证识别，front 为 true 识别人像面，否则识别国徽面
+func (b *BaiduOcr) IdCard(image Image, front bool) (*IdCardResponse, error) {
+	return b.IdCardCtx(context.Background(), image, front)
+}
+
+// IdCardCtx 同 IdCard，ctx 取消时中止请求
+func (b *BaiduOcr) IdCardCtx(ctx context.Context, image Image, front bool) (*IdCardResponse, error) {
+	side := "back"
+	if front {
+		side = "front"
+	}
+	res := &IdCardResponse{}
+	return res, b.RecognizeCtx(ctx, IdCard, image, url.Values{"id_card_side": {side}}, res)
+}
+
+// BankCard 银行卡识别
+func (b *BaiduOcr) BankCard(image Image) (*BankCardResponse, error) {
+	return b.BankCardCtx(context.Background(), image)
+}
+
+// BankCardCtx 同 BankCard，ctx 取消时中止请求
+func (b *BaiduOcr) BankCardCtx(ctx context.Context, image Image) (*BankCardResponse, error) {
+	res := &BankCardResponse{}
+	return res, b.RecognizeCtx(ctx, BankCard, image, nil, res)
+}
+
+// BusinessLicense 营业执照识别
+func (b *BaiduOcr) BusinessLicense(image Image) (*BusinessLicenseResponse, error) {
+	return b.BusinessLicenseCtx(context.Background(), image)
+}
+
+// BusinessLicenseCtx 同 BusinessLicense，ctx 取消时中止请求
+func (b *BaiduOcr) BusinessLicenseCtx(ctx context.Context, image Image) (*BusinessLicenseResponse, error) {
+	res := &BusinessLicenseResponse{}
+	return res, b.RecognizeCtx(ctx, BusinessLicense, image, nil, res)
+}
+
+// VatInvoice 增值税发票识别
+func (b *BaiduOcr) VatInvoice(image Image) (*VatInvoiceResponse, error) {
+	return b.VatInvoiceCtx(context.Background(), image)
+}
+
+// VatInvoiceCtx 同 VatInvoice，ctx 取消时中止请求
+func (b *BaiduOcr) VatInvoiceCtx(ctx context.Context, image Image) (*VatInvoiceResponse, error) {
+	res := &VatInvoiceResponse{}
+	return res, b.RecognizeCtx(ctx, VatInvoice, image, nil, res)
+}
+
+// TrainTicket 火车票识别
+func (b *BaiduOcr) TrainTicket(image Image) (*TrainTicketResponse, error) {
+	return b.TrainTicketCtx(context.Background(), image)
+}
+
+// TrainTicketCtx 同 TrainTicket，ctx 取消时中止请求
+func (b *BaiduOcr) TrainTicketCtx(ctx context.Context, image Image) (*TrainTicketResponse, error) {
+	res := &TrainTicketResponse{}
+	return res, b.RecognizeCtx(ctx, TrainTicket, image, nil, res)
+}
+
+// Receipt 通用票据识别
+func (b *BaiduOcr) Receipt(image Image) (*LocationResponse, error) {
+	return b.ReceiptCtx(context.Background(), image)
+}
+
+// ReceiptCtx 同 Receipt，ctx 取消时中止请求
+func (b *BaiduOcr) ReceiptCtx(ctx context.Context, image Image) (*LocationResponse, error) {
+	res := &LocationResponse{}
+	return res, b.RecognizeCtx(ctx, Receipt, image, nil, res)
+}
+
+// Text 按行拼接识别出的文字
+func (r *WordsResponse) Text() string {
+	lines := make([]string, 0, len(r.WordsResult))
+	for _, w := range r.WordsResult {
+		lines = append(lines, w.Words)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Text 按行拼接识别出的文字
+func (r *LocationResponse) Text() string {
+	lines := make([]string, 0, len(r.WordsResult))
+	for _, w := range r.WordsResult {
+		lines = append(lines, w.Words)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -1,9 +1,14 @@
 package baidu
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
+	"github.com/comqositi/toolkits/thirdsdk/fetcher"
+	"github.com/comqositi/toolkits/thirdsdk/filetype"
+	"github.com/comqositi/toolkits/thirdsdk/httpx"
+	"github.com/comqositi/toolkits/thirdsdk/logx"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -14,11 +19,29 @@ import (
 )
 
 var (
-	tokenUrlBaiDu     = "https://aip.baidubce.com/oauth/2.0/token"
-	transformUrlBaidu = "https://aip.baidubce.com/rest/2.0/ocr/v1/general_basic?access_token=%s"
+	tokenUrlBaiDu    = "https://aip.baidubce.com/oauth/2.0/token"
+	endpointUrlBaidu = "https://aip.baidubce.com/rest/2.0/ocr/v1/%s?access_token=%s"
+)
+
+const providerName = "baidu"
+
+const (
+	// maxImageSize 按地址识别时图片的最大字节数
+	maxImageSize = 8 << 20
+	// maxPdfPages 整份 pdf 识别时的最大页数
+	maxPdfPages = 21
+)
+
+var (
+	errNotPdf        = errorx.New(errorx.ErrUnsupportedFormat, "not a pdf file")
+	errTooManyPages  = errorx.Errorf(errorx.ErrTooManyPages, "pdf exceeds %d pages", maxPdfPages)
+	errPdfTooLarge   = errorx.New(errorx.ErrTooLarge, "pdf exceeds 5MB")
+	errImageTooLarge = errorx.New(errorx.ErrTooLarge, "image exceeds 8MB")
+	errUrlTooLong    = errorx.New(errorx.ErrInvalidInput, "image url exceeds 1024 bytes")
 )
 
 type BodyResultResponse struct {
+	ErrorResponse
 	LogId          int         `json:"log_id"`
 	WordsResultNum int         `json:"words_result_num"`
 	WordsResult    []WordsList `json:"words_result"`
@@ -45,40 +68,83 @@ type Cache interface {
 }
 
 type BaiduOcr struct {
-	cache     Cache
-	apiKey    string
-	apiSecret string
+	cache      Cache
+	apiKey     string
+	apiSecret  string
+	options    Options
+	httpClient *http.Client
+	logger     logx.Logger
+}
+
+// ClientOption 创建客户端时的可选配置
+type ClientOption func(b *BaiduOcr)
+
+// WithHttpClient 指定请求使用的 http 客户端，可用于设置代理、链路追踪或测试服务器；为空时使用 httpx.Default()
+func WithHttpClient(c *http.Client) ClientOption {
+	return func(b *BaiduOcr) {
+		b.httpClient = c
+	}
 }
 
-func NewBaiduOcr(apiKey string, apiSecret string, cache Cache) (*BaiduOcr, error) {
+// WithLogger 指定日志，为空时使用 logx.Default()
+func WithLogger(l logx.Logger) ClientOption {
+	return func(b *BaiduOcr) {
+		b.logger = l
+	}
+}
+
+// NewBaiduOcr 创建百度识别客户端，创建时会获取 access token
+func NewBaiduOcr(apiKey string, apiSecret string, cache Cache, opts ...ClientOption) (*BaiduOcr, error) {
+	return NewBaiduOcrCtx(context.Background(), apiKey, apiSecret, cache, opts...)
+}
+
+// NewBaiduOcrCtx 同 NewBaiduOcr，ctx 取消时中止请求
+func NewBaiduOcrCtx(ctx context.Context, apiKey string, apiSecret string, cache Cache, opts ...ClientOption) (*BaiduOcr, error) {
 	c := &BaiduOcr{apiKey: apiKey, apiSecret: apiSecret, cache: cache}
-	_, err := c.getAccessToken()
+	for _, opt := range opts {
+		opt(c)
+	}
+	_, err := c.getAccessToken(ctx)
 	if err != nil {
 		return nil, err
 	}
 	return c, nil
 }
 
+// Fetcher 返回使用 WithHttpClient 指定客户端的下载器，未指定时为 fetcher.Default()
+func (b *BaiduOcr) Fetcher() *fetcher.Fetcher {
+	return fetcher.ForClient(b.httpClient)
+}
+
 // 图片转文字
 func (b *BaiduOcr) ImageToWord(filePath string) (word string, fileSuffix string, FileSize int, err error) {
-	suffix, err := getSuffix(filePath)
+	return b.ImageToWordCtx(context.Background(), filePath)
+}
+
+// ImageToWordCtx 同 ImageToWord，ctx 取消时中止请求
+func (b *BaiduOcr) ImageToWordCtx(ctx context.Context, filePath string) (word string, fileSuffix string, FileSize int, err error) {
+	t, err := filetype.DetectFile(filePath)
 	if err != nil {
-		return "", "", 0, errors.New("获取前缀失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrUnsupportedFormat, err)
+	}
+	suffix, err := checkImage(t)
+	if err != nil {
+		return "", "", 0, err
 	}
 	size, err := countSize(filePath)
 	if err != nil {
-		return "", "", 0, errors.New("计算文件大小失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrRead, err)
 	}
 
 	encode := b.getFileContentAsBase64(filePath)
 	contextLen := len(encode)
 	if contextLen/1024/1024 > 8 {
-		return "", "", 0, errors.New("文件大小不能大于8M！")
+		return "", "", 0, errImageTooLarge
 	}
-	payload := strings.NewReader("image=" + url.QueryEscape(encode) + "&detect_direction=false&detect_language=false&paragraph=false&probability=false")
-	str, err := b.commonFun(payload)
+	payload := strings.NewReader("image=" + url.QueryEscape(encode) + "&" + b.options.values().Encode())
+	str, err := b.commonFun(ctx, payload)
 	if err != nil {
-		return "", "", 0, errors.New("word文档解析失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrProvider, err)
 	}
 
 	return str, suffix, size, nil
@@ -86,29 +152,30 @@ func (b *BaiduOcr) ImageToWord(filePath string) (word string, fileSuffix string,
 
 // 图片地址转文字
 func (b *BaiduOcr) ImageUrlToWord(imageUrl string) (word string, fileSuffix string, FileSize int, err error) {
-	suffix, err := getSuffix(imageUrl)
-	if err != nil {
-		return "", "", 0, errors.New("获取前缀失败！")
-	}
+	return b.ImageUrlToWordCtx(context.Background(), imageUrl)
+}
 
-	size, err := countImgSize(imageUrl)
+// ImageUrlToWordCtx 同 ImageUrlToWord，ctx 取消时中止请求
+func (b *BaiduOcr) ImageUrlToWordCtx(ctx context.Context, imageUrl string) (word string, fileSuffix string, FileSize int, err error) {
+	if len(imageUrl) > 1024 {
+		return "", "", 0, errUrlTooLong
+	}
+	// 百度按地址自行下载，这里下载一次只为检查格式和大小
+	size, t, err := countImgSize(ctx, b.httpClient, imageUrl)
 	if err != nil {
-		return "", "", 0, errors.New("获取图片大小失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrDownload, err)
 	}
-
-	if len(imageUrl) > 1024 {
-		return "", "", 0, errors.New("图片地址不能超过 1024 个字节")
+	suffix, err := checkImage(t)
+	if err != nil {
+		return "", "", 0, err
 	}
-
-	encode := b.getFileContentAsBase64(imageUrl)
-	contextLen := len(encode)
-	if contextLen/1024/1024 > 8 {
-		return "", "", 0, errors.New("文件大小不能大于8M")
+	if size > maxImageSize {
+		return "", "", 0, errImageTooLarge
 	}
-	payload := strings.NewReader("url=" + url.QueryEscape(imageUrl) + "&detect_direction=false&detect_language=false&paragraph=false&probability=false")
-	str, err := b.commonFun(payload)
+	payload := strings.NewReader("url=" + url.QueryEscape(imageUrl) + "&" + b.options.values().Encode())
+	str, err := b.commonFun(ctx, payload)
 	if err != nil {
-		return "", "", 0, errors.New("word文档解析失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrProvider, err)
 	}
 
 	return str, suffix, size, nil
@@ -116,21 +183,27 @@ func (b *BaiduOcr) ImageUrlToWord(imageUrl string) (word string, fileSuffix stri
 
 // pdf转文字
 func (b *BaiduOcr) PdfToWord(filePath string) (word string, fileSuffix string, FileSize int, err error) {
+	return b.PdfToWordCtx(context.Background(), filePath)
+}
+
+// PdfToWordCtx 同 PdfToWord，ctx 取消时中止请求
+func (b *BaiduOcr) PdfToWordCtx(ctx context.Context, filePath string) (word string, fileSuffix string, FileSize int, err error) {
+	t, err := filetype.DetectFile(filePath)
+	if err != nil || t != filetype.Pdf {
+		return "", "", 0, errNotPdf
+	}
 	numPages, err := getPdfNum(filePath)
 	if err != nil {
 		return "", "", 0, err
 	}
-	if numPages > 21 {
-		return "", "", 0, errors.New("pdf文件不能超过20页！")
+	if numPages > maxPdfPages {
+		return "", "", 0, errTooManyPages
 	}
 
-	suffix, err := getSuffix(filePath)
-	if err != nil {
-		return "", "", 0, errors.New("获取前缀失败！")
-	}
+	suffix := t.Ext
 	size, err := countSize(filePath)
 	if err != nil {
-		return "", "", 0, errors.New("计算文件大小失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrRead, err)
 	}
 
 	defer os.Remove(filePath)
@@ -138,17 +211,19 @@ func (b *BaiduOcr) PdfToWord(filePath string) (word string, fileSuffix string, F
 	encode := b.getFileContentAsBase64(filePath)
 	contextLen := len(encode)
 	if contextLen/1024/1024 > 5 {
-		return "", "", 0, errors.New("文件大小不能大于5M")
+		return "", "", 0, errPdfTooLarge
 	}
 
 	var result string
 	for i := 1; i < numPages+1; i++ {
-		time.Sleep(time.Millisecond * 300)
-		payload := strings.NewReader("pdf_file=" + url.QueryEscape(encode) + "&pdf_file_num=" + strconv.Itoa(i) + "&detect_direction=false&detect_language=false&paragraph=false&probability=false")
+		if err := sleepCtx(ctx, time.Millisecond*300); err != nil {
+			return "", "", 0, err
+		}
+		payload := strings.NewReader("pdf_file=" + url.QueryEscape(encode) + "&pdf_file_num=" + strconv.Itoa(i) + "&" + b.options.values().Encode())
 
-		str, err := b.commonFun(payload)
+		str, err := b.commonFun(ctx, payload)
 		if err != nil {
-			return "", "", 0, errors.New("pdf解析失败！")
+			return "", "", 0, errorx.Wrap(errorx.ErrProvider, err)
 		}
 		result += str
 	}
@@ -158,44 +233,53 @@ func (b *BaiduOcr) PdfToWord(filePath string) (word string, fileSuffix string, F
 
 // pdf转文字
 func (b *BaiduOcr) PdfUrlToWord(pdfUrl string) (word string, fileSuffix string, FileSize int, err error) {
-	suffix, err := getSuffix(pdfUrl)
+	return b.PdfUrlToWordCtx(context.Background(), pdfUrl)
+}
+
+// PdfUrlToWordCtx 同 PdfUrlToWord，ctx 取消时中止请求
+func (b *BaiduOcr) PdfUrlToWordCtx(ctx context.Context, pdfUrl string) (word string, fileSuffix string, FileSize int, err error) {
+	suffix, _ := getSuffix(pdfUrl)
+	filePath, err := saveFile(ctx, b.httpClient, pdfUrl, suffix)
 	if err != nil {
-		return "", "", 0, errors.New("获取前缀失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrDownload, err)
 	}
+	defer os.Remove(filePath)
 
-	filePath, err := saveFile(pdfUrl, suffix)
-	if err != nil {
-		return "", "", 0, errors.New("文件保存在本地失败！")
+	t, err := filetype.DetectFile(filePath)
+	if err != nil || t != filetype.Pdf {
+		return "", "", 0, errNotPdf
 	}
+	suffix = t.Ext
 
 	numPages, err := getPdfNum(filePath)
 	if err != nil {
 		return "", "", 0, err
 	}
-	if numPages > 21 {
-		return "", "", 0, errors.New("pdf文件不能超过20页！")
+	if numPages > maxPdfPages {
+		return "", "", 0, errTooManyPages
 	}
-	defer os.Remove(filePath)
 
 	size, err := countSize(filePath)
 	if err != nil {
-		return "", "", 0, errors.New("计算文件大小失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrRead, err)
 	}
 
 	encode := b.getFileContentAsBase64(filePath)
 	contextLen := len(encode)
 	if contextLen/1024/1024 > 5 {
-		return "", "", 0, errors.New("文件大小不能大于5M")
+		return "", "", 0, errPdfTooLarge
 	}
 
 	var result string
 	for i := 1; i < numPages+1; i++ {
-		time.Sleep(time.Millisecond * 300)
-		payload := strings.NewReader("pdf_file=" + url.QueryEscape(encode) + "&pdf_file_num=" + strconv.Itoa(i) + "&detect_direction=false&detect_language=false&paragraph=false&probability=false")
+		if err := sleepCtx(ctx, time.Millisecond*300); err != nil {
+			return "", "", 0, err
+		}
+		payload := strings.NewReader("pdf_file=" + url.QueryEscape(encode) + "&pdf_file_num=" + strconv.Itoa(i) + "&" + b.options.values().Encode())
 
-		str, err := b.commonFun(payload)
+		str, err := b.commonFun(ctx, payload)
 		if err != nil {
-			return "", "", 0, errors.New("pdf解析失败！")
+			return "", "", 0, errorx.Wrap(errorx.ErrProvider, err)
 		}
 		result += str
 	}
@@ -203,67 +287,103 @@ func (b *BaiduOcr) PdfUrlToWord(pdfUrl string) (word string, fileSuffix string,
 	return result, suffix, size, nil
 }
 
+// PdfPageToWord pdf指定页转文字，page 从 1 开始
+func (b *BaiduOcr) PdfPageToWord(filePath string, page int) (word string, fileSuffix string, FileSize int, err error) {
+	return b.PdfPageToWordCtx(context.Background(), filePath, page)
+}
+
+// PdfPageToWordCtx 同 PdfPageToWord，ctx 取消时中止请求
+func (b *BaiduOcr) PdfPageToWordCtx(ctx context.Context, filePath string, page int) (word string, fileSuffix string, FileSize int, err error) {
+	t, err := filetype.DetectFile(filePath)
+	if err != nil || t != filetype.Pdf {
+		return "", "", 0, errNotPdf
+	}
+	numPages, err := getPdfNum(filePath)
+	if err != nil {
+		return "", "", 0, err
+	}
+	if page < 1 || page > numPages {
+		return "", "", 0, errorx.ErrPageRange
+	}
+
+	size, err := countSize(filePath)
+	if err != nil {
+		return "", "", 0, errorx.Wrap(errorx.ErrRead, err)
+	}
+
+	encode := b.getFileContentAsBase64(filePath)
+	contextLen := len(encode)
+	if contextLen/1024/1024 > 5 {
+		return "", "", 0, errPdfTooLarge
+	}
+
+	payload := strings.NewReader("pdf_file=" + url.QueryEscape(encode) + "&pdf_file_num=" + strconv.Itoa(page) + "&" + b.options.values().Encode())
+	str, err := b.commonFun(ctx, payload)
+	if err != nil {
+		return "", "", 0, errorx.Wrap(errorx.ErrProvider, err)
+	}
+
+	return str, t.Ext, size, nil
+}
+
 // 获取token
-func (b *BaiduOcr) getAccessToken() (token string, err error) {
+func (b *BaiduOcr) getAccessToken(ctx context.Context) (token string, err error) {
+	log := logx.Or(b.logger)
 
 	md5String, _ := md5ByString(b.apiKey)
 	tokenKey := "kpai:baiduocr:" + md5String
 	token, err = b.cache.Get(tokenKey)
 	if err != nil {
-		fmt.Printf("baidu gettoken redis token, err = %v \n", err)
+		log.Warn("baidu get token from cache failed", "err", err)
 	}
 	if len(token) > 1 {
-		fmt.Printf("baidu gettoken redis token is not empty, token 1%v1 \n", token)
+		log.Debug("baidu token from cache", "token", logx.Redact(token))
 		return token, nil
 	}
 
 	url := tokenUrlBaiDu + "?client_id=%s&client_secret=%s&grant_type=client_credentials"
 	url = fmt.Sprintf(url, b.apiKey, b.apiSecret)
 	payload := strings.NewReader(``)
-	client := &http.Client{}
-	req, err := http.NewRequest("POST", url, payload)
+	client := httpx.Or(b.httpClient)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, payload)
 	if err != nil {
-		fmt.Printf("baidu gettoken http.NewRequest, err %v\n", err)
-		return token, err
+		return token, logx.RedactError(err)
 	}
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("Accept", "application/json")
 
 	res, err := client.Do(req)
 	if err != nil {
-		fmt.Printf("baidu gettoken http.NewRequest Do, err %v\n", err)
+		err = &errorx.ProviderError{Provider: providerName, Err: logx.RedactError(err)}
+		log.Error("baidu request token failed", "err", err)
 		return token, err
 	}
 	defer res.Body.Close()
 
 	body, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		fmt.Printf("baidu gettoken http.NewRequest ioutil.ReadAll, err %v\n", err)
+		err = &errorx.ProviderError{Provider: providerName, StatusCode: res.StatusCode, Err: err}
+		log.Error("baidu read token response failed", "err", err)
 		return token, err
 	}
 
 	var baiDuTokenResponse BaiDuTokenResponse
 	err = json.Unmarshal(body, &baiDuTokenResponse)
 	if err != nil {
-		fmt.Printf("baidu gettoken http.NewRequest json.Unmarshal, body %v ;err %v\n", string(body), err)
-		return token, err
+		log.Error("baidu parse token response failed", "status", res.StatusCode, "err", err)
+		return token, invalidResponse(err)
 	}
 	if len(baiDuTokenResponse.Error) > 1 {
-		fmt.Printf("baidu gettoken http.NewRequest err, ErrorMsg %v, ErrorCode %v \n", baiDuTokenResponse.Error, baiDuTokenResponse.ErrorDescription)
-		return token, err
+		log.Error("baidu token rejected", "code", baiDuTokenResponse.Error, "description", baiDuTokenResponse.ErrorDescription)
+		return token, &errorx.ProviderError{Provider: providerName, StatusCode: res.StatusCode, Code: baiDuTokenResponse.Error, Message: baiDuTokenResponse.ErrorDescription}
 	}
 
 	token = baiDuTokenResponse.AccessToken
 	if len(token) > 0 {
-		fmt.Printf("baidu translate save token, %v  \n", token)
-		if err != nil {
-			fmt.Printf("baidu gettoken save token, %v  \n", err.Error())
-			return token, err
-		}
-
+		log.Debug("baidu token refreshed", "token", logx.Redact(token), "expires_in", baiDuTokenResponse.ExpiresIn)
 		err = b.cache.Set(tokenKey, token, int(baiDuTokenResponse.ExpiresIn))
 		if err != nil {
-			fmt.Printf("baidu gettoken save token Expire,token = %v  err = %v \n", token, err)
+			log.Warn("baidu save token to cache failed", "err", err)
 			return token, err
 		}
 	}
@@ -0,0 +1,166 @@
+package baidu
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Options 通用文字识别参数，零值与原有行为一致（全部关闭）
+type Options struct {
+	// DetectDirection 检测图片朝向
+	DetectDirection bool
+	// DetectLanguage 检测语种，仅标准版支持
+	DetectLanguage bool
+	// LanguageType 识别语种，如 CHN_ENG、ENG、JAP、KOR，为空时使用接口默认值
+	LanguageType string
+	// Paragraph 输出段落信息
+	Paragraph bool
+	// Probability 输出每行的置信度
+	Probability bool
+}
+
+func (o Options) values() url.Values {
+	v := url.Values{}
+	v.Set("detect_direction", strconv.FormatBool(o.DetectDirection))
+	v.Set("detect_language", strconv.FormatBool(o.DetectLanguage))
+	v.Set("paragraph", strconv.FormatBool(o.Paragraph))
+	v.Set("probability", strconv.FormatBool(o.Probability))
+	if o.LanguageType != "" {
+		v.Set("language_type", o.LanguageType)
+	}
+	return v
+}
+
+// WithOptions 返回使用指定参数的副本，原实例不受影响，可并发使用
+func (b *BaiduOcr) WithOptions(opts Options) *BaiduOcr {
+	c := *b
+	c.options = opts
+	return &c
+}
+
+// Probability 行置信度，取值 0 到 1
+type Probability struct {
+	Average  float64 `json:"average"`
+	Min      float64 `json:"min"`
+	Variance float64 `json:"variance"`
+}
+
+// RichWords 识别出的一行文字，不含位置的接口 Location 为零值
+type RichWords struct {
+	Words       string      `json:"words"`
+	Location    Location    `json:"location"`
+	Probability Probability `json:"probability"`
+}
+
+// ParagraphResult 段落包含的行在 WordsResult 中的下标
+type ParagraphResult struct {
+	WordsResultIdx []int `json:"words_result_idx"`
+}
+
+// RichResponse 开启段落、置信度后的通用文字识别结果
+type RichResponse struct {
+	ErrorResponse
+	LogId            int64             `json:"log_id"`
+	Direction        int               `json:"direction"`
+	Language         int               `json:"language"`
+	WordsResultNum   int               `json:"words_result_num"`
+	WordsResult      []RichWords       `json:"words_result"`
+	ParagraphsResult []ParagraphResult `json:"paragraphs_result"`
+}
+
+// Paragraph 段落，Location 为各行区域的外接矩形
+type Paragraph struct {
+	Text     string
+	Location Location
+	Lines    []RichWords
+}
+
+// RecognizeRich 调用通用文字识别类接口并返回位置、置信度和段落信息，需要配合 WithOptions 开启 Paragraph、Probability
+func (b *BaiduOcr) RecognizeRich(endpoint Endpoint, image Image) (*RichResponse, error) {
+	return b.RecognizeRichCtx(context.Background(), endpoint, image)
+}
+
+// RecognizeRichCtx 同 RecognizeRich，ctx 取消时中止请求
+func (b *BaiduOcr) RecognizeRichCtx(ctx context.Context, endpoint Endpoint, image Image) (*RichResponse, error) {
+	res := &RichResponse{}
+	return res, b.RecognizeCtx(ctx, endpoint, image, nil, res)
+}
+
+// Lines 返回平均置信度不低于 minProbability 的行；未开启置信度时返回全部行
+func (r *RichResponse) Lines(minProbability float64) []RichWords {
+	var lines []RichWords
+	for _, w := range r.WordsResult {
+		if w.Probability == (Probability{}) || w.Probability.Average >= minProbability {
+			lines = append(lines, w)
+		}
+	}
+	return lines
+}
+
+// Paragraphs 按段落返回识别结果；未开启段落时每行作为一段
+func (r *RichResponse) Paragraphs() []Paragraph {
+	if len(r.ParagraphsResult) == 0 {
+		paragraphs := make([]Paragraph, 0, len(r.WordsResult))
+		for _, w := range r.WordsResult {
+			paragraphs = append(paragraphs, Paragraph{Text: w.Words, Location: w.Location, Lines: []RichWords{w}})
+		}
+		return paragraphs
+	}
+
+	paragraphs := make([]Paragraph, 0, len(r.ParagraphsResult))
+	for _, p := range r.ParagraphsResult {
+		var (
+			para  Paragraph
+			texts []string
+		)
+		for _, idx := range p.WordsResultIdx {
+			if idx < 0 || idx >= len(r.WordsResult) {
+				continue
+			}
+			w := r.WordsResult[idx]
+			para.Lines = append(para.Lines, w)
+			texts = append(texts, w.Words)
+			para.Location = unionLocation(para.Location, w.Location, len(para.Lines) == 1)
+		}
+		para.Text = strings.Join(texts, "\n")
+		paragraphs = append(paragraphs, para)
+	}
+	return paragraphs
+}
+
+// Text 段落之间空一行，段内按行拼接
+func (r *RichResponse) Text() string {
+	paragraphs := r.Paragraphs()
+	texts := make([]string, 0, len(paragraphs))
+	for _, p := range paragraphs {
+		texts = append(texts, p.Text)
+	}
+	sep := "\n"
+	if len(r.ParagraphsResult) > 0 {
+		sep = "\n\n"
+	}
+	return strings.Join(texts, sep)
+}
+
+func unionLocation(a, b Location, first bool) Location {
+	if first {
+		return b
+	}
+	left, top := a.Left, a.Top
+	if b.Left < left {
+		left = b.Left
+	}
+	if b.Top < top {
+		top = b.Top
+	}
+	right, bottom := a.Left+a.Width, a.Top+a.Height
+	if r := b.Left + b.Width; r > right {
+		right = r
+	}
+	if d := b.Top + b.Height; d > bottom {
+		bottom = d
+	}
+	return Location{Left: left, Top: top, Width: right - left, Height: bottom - top}
+}
@@ -0,0 +1,195 @@
+package baidu
+
+import (
+	"strconv"
+
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
+)
+
+// ErrorResponse 百度接口的错误信息，ErrorCode 为 0 表示成功
+type ErrorResponse struct {
+	ErrorCode int    `json:"error_code,omitempty"`
+	ErrorMsg  string `json:"error_msg,omitempty"`
+}
+
+func (e ErrorResponse) apiError() error {
+	if e.ErrorCode == 0 {
+		return nil
+	}
+	return &errorx.ProviderError{Provider: providerName, Code: strconv.Itoa(e.ErrorCode), Message: e.ErrorMsg}
+}
+
+// Location 文字区域，单位为像素
+type Location struct {
+	Left   int `json:"left"`
+	Top    int `json:"top"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// Point 顶点坐标
+type Point struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// Field 卡证类接口中的单个字段
+type Field struct {
+	Words    string   `json:"words"`
+	Location Location `json:"location"`
+}
+
+// WordsResponse 通用文字识别（general_basic、accurate_basic）
+type WordsResponse struct {
+	ErrorResponse
+	LogId          int64       `json:"log_id"`
+	Direction      int         `json:"direction"`
+	WordsResultNum int         `json:"words_result_num"`
+	WordsResult    []WordsList `json:"words_result"`
+}
+
+// LocationWords 带位置的一行文字
+type LocationWords struct {
+	Words    string   `json:"words"`
+	Location Location `json:"location"`
+}
+
+// LocationResponse 含位置信息的识别（general、accurate、handwriting、receipt）
+type LocationResponse struct {
+	ErrorResponse
+	LogId          int64           `json:"log_id"`
+	Direction      int             `json:"direction"`
+	WordsResultNum int             `json:"words_result_num"`
+	WordsResult    []LocationWords `json:"words_result"`
+}
+
+// TableCell 表格单元格，行列从 0 开始，合并单元格的结束行列大于起始行列
+type TableCell struct {
+	CellLocation []Point `json:"cell_location"`
+	RowStart     int     `json:"row_start"`
+	RowEnd       int     `json:"row_end"`
+	ColStart     int     `json:"col_start"`
+	ColEnd       int     `json:"col_end"`
+	Words        string  `json:"words"`
+}
+
+// TableHeader 表头或表尾
+type TableHeader struct {
+	Location []Point `json:"location"`
+	Words    string  `json:"words"`
+}
+
+// TableResult 单张表格
+type TableResult struct {
+	TableLocation []Point       `json:"table_location"`
+	Header        []TableHeader `json:"header"`
+	Body          []TableCell   `json:"body"`
+	Footer        []TableHeader `json:"footer"`
+}
+
+// TableResponse 表格文字识别（table）
+type TableResponse struct {
+	ErrorResponse
+	LogId        int64         `json:"log_id"`
+	TableNum     int           `json:"table_num"`
+	TablesResult []TableResult `json:"tables_result"`
+}
+
+// IdCardResponse 身份证识别（idcard），WordsResult 的键为 姓名、性别、民族、出生、住址、公民身份号码、签发机关、签发日期、失效日期 等
+type IdCardResponse struct {
+	ErrorResponse
+	LogId            int64            `json:"log_id"`
+	Direction        int              `json:"direction"`
+	ImageStatus      string           `json:"image_status"`
+	RiskType         string           `json:"risk_type"`
+	IdcardNumberType int              `json:"idcard_number_type"`
+	WordsResultNum   int              `json:"words_result_num"`
+	WordsResult      map[string]Field `json:"words_result"`
+}
+
+// BankCardResult 银行卡信息，BankCardType 0 为无法识别，1 借记卡，2 贷记卡，3 准贷记卡，4 预付费卡
+type BankCardResult struct {
+	BankCardNumber string `json:"bank_card_number"`
+	ValidDate      string `json:"valid_date"`
+	BankCardType   int    `json:"bank_card_type"`
+	BankName       string `json:"bank_name"`
+	HolderName     string `json:"holder_name"`
+}
+
+// BankCardResponse 银行卡识别（bankcard）
+type BankCardResponse struct {
+	ErrorResponse
+	LogId     int64          `json:"log_id"`
+	Direction int            `json:"direction"`
+	Result    BankCardResult `json:"result"`
+}
+
+// BusinessLicenseResponse 营业执照识别（business_license），WordsResult 的键为 单位名称、法人、社会信用代码、地址、成立日期、有效期 等
+type BusinessLicenseResponse struct {
+	ErrorResponse
+	LogId          int64            `json:"log_id"`
+	Direction      int              `json:"direction"`
+	WordsResultNum int              `json:"words_result_num"`
+	WordsResult    map[string]Field `json:"words_result"`
+}
+
+// InvoiceItem 发票明细中的一项
+type InvoiceItem struct {
+	Row  string `json:"row"`
+	Word string `json:"word"`
+}
+
+// VatInvoiceResult 增值税发票字段
+type VatInvoiceResult struct {
+	InvoiceType          string        `json:"InvoiceType"`
+	InvoiceCode          string        `json:"InvoiceCode"`
+	InvoiceNum           string        `json:"InvoiceNum"`
+	InvoiceDate          string        `json:"InvoiceDate"`
+	CheckCode            string        `json:"CheckCode"`
+	PurchaserName        string        `json:"PurchaserName"`
+	PurchaserRegisterNum string        `json:"PurchaserRegisterNum"`
+	SellerName           string        `json:"SellerName"`
+	SellerRegisterNum    string        `json:"SellerRegisterNum"`
+	TotalAmount          string        `json:"TotalAmount"`
+	TotalTax             string        `json:"TotalTax"`
+	AmountInFiguers      string        `json:"AmountInFiguers"`
+	AmountInWords        string        `json:"AmountInWords"`
+	Remarks              string        `json:"Remarks"`
+	CommodityName        []InvoiceItem `json:"CommodityName"`
+	CommodityNum         []InvoiceItem `json:"CommodityNum"`
+	CommodityPrice       []InvoiceItem `json:"CommodityPrice"`
+	CommodityAmount      []InvoiceItem `json:"CommodityAmount"`
+	CommodityTaxRate     []InvoiceItem `json:"CommodityTaxRate"`
+	CommodityTax         []InvoiceItem `json:"CommodityTax"`
+}
+
+// VatInvoiceResponse 增值税发票识别（vat_invoice）
+type VatInvoiceResponse struct {
+	ErrorResponse
+	LogId          int64            `json:"log_id"`
+	WordsResultNum int              `json:"words_result_num"`
+	WordsResult    VatInvoiceResult `json:"words_result"`
+}
+
+// TrainTicketResult 火车票字段
+type TrainTicketResult struct {
+	TicketNum          string `json:"ticket_num"`
+	TrainNum           string `json:"train_num"`
+	StartingStation    string `json:"starting_station"`
+	DestinationStation string `json:"destination_station"`
+	Date               string `json:"date"`
+	Time               string `json:"time"`
+	TicketRates        string `json:"ticket_rates"`
+	SeatCategory       string `json:"seat_category"`
+	SeatNum            string `json:"seat_num"`
+	Name               string `json:"name"`
+	IdNum              string `json:"ID_card"`
+}
+
+// TrainTicketResponse 火车票识别（train_ticket）
+type TrainTicketResponse struct {
+	ErrorResponse
+	LogId          int64             `json:"log_id"`
+	WordsResultNum int               `json:"words_result_num"`
+	WordsResult    TrainTicketResult `json:"words_result"`
+}
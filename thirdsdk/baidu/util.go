@@ -1,40 +1,73 @@
 package baidu
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/base64"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
+	"github.com/comqositi/toolkits/thirdsdk/fetcher"
+	"github.com/comqositi/toolkits/thirdsdk/filetype"
+	"github.com/comqositi/toolkits/thirdsdk/httpx"
+	"github.com/comqositi/toolkits/thirdsdk/logx"
 	"github.com/unidoc/unipdf/v3/model"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 )
 
-func (b *BaiduOcr) commonFun(payload *strings.Reader) (word string, err error) {
-	token, err := b.getAccessToken()
+func (b *BaiduOcr) commonFun(ctx context.Context, payload *strings.Reader) (word string, err error) {
+	body, err := b.post(ctx, GeneralBasic, payload)
 	if err != nil {
 		return "", err
 	}
+	resBody1 := BodyResultResponse{}
+	err = json.Unmarshal(body, &resBody1)
+	if err != nil {
+		return "", invalidResponse(err)
+	}
+	if err := resBody1.apiError(); err != nil {
+		logx.Or(b.logger).Warn("baidu recognize failed", "endpoint", GeneralBasic, "err", err)
+		return "", err
+	}
 
-	requestUrl := fmt.Sprintf(transformUrlBaidu, token)
+	var str string
+	for _, val := range resBody1.WordsResult {
+		str += val.Words + " "
+	}
+	str = strings.TrimRight(str, " ")
 
-	client := &http.Client{}
-	req, err := http.NewRequest("POST", requestUrl, payload)
+	return str, nil
+}
 
+// post 以表单方式请求识别接口，返回响应体
+func (b *BaiduOcr) post(ctx context.Context, endpoint Endpoint, payload io.Reader) ([]byte, error) {
+	token, err := b.getAccessToken(ctx)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	requestUrl := fmt.Sprintf(endpointUrlBaidu, endpoint, token)
+
+	client := httpx.Or(b.httpClient)
+	req, err := http.NewRequestWithContext(ctx, "POST", requestUrl, payload)
+
+	if err != nil {
+		return nil, err
 	}
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Add("Accept", "application/json")
 
 	res, err := client.Do(req)
 	if err != nil {
-		fmt.Println(err)
-		return
+		err = &errorx.ProviderError{Provider: providerName, Err: logx.RedactError(err)}
+		logx.Or(b.logger).Error("baidu request failed", "endpoint", endpoint, "err", err)
+		return nil, err
 	}
 	defer func(Body io.ReadCloser) {
 		err := Body.Close()
@@ -43,106 +76,86 @@ func (b *BaiduOcr) commonFun(payload *strings.Reader) (word string, err error) {
 		}
 	}(res.Body)
 
-	body, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		return
+	if res.StatusCode >= http.StatusMultipleChoices {
+		logx.Or(b.logger).Error("baidu request failed", "endpoint", endpoint, "status", res.StatusCode)
+		return nil, &errorx.ProviderError{Provider: providerName, StatusCode: res.StatusCode, Message: res.Status}
 	}
-	resBody1 := BodyResultResponse{}
-	err = json.Unmarshal(body, &resBody1)
+	body, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		return
+		return nil, &errorx.ProviderError{Provider: providerName, StatusCode: res.StatusCode, Err: err}
 	}
-
-	var str string
-	for _, val := range resBody1.WordsResult {
-		str += val.Words + " "
-	}
-	str = strings.TrimRight(str, " ")
-
-	return str, nil
+	return body, nil
 }
 
-func saveFile(url string, suffix string) (string, error) {
-	byString, err := md5ByString(url)
-	if err != nil {
-		return "", err
-	}
-	targetName := "temporary" + byString + "." + suffix
-
-	// 发起GET请求
-	response, err := http.Get(url)
-	if err != nil {
-		return "", err
-	}
-	defer response.Body.Close()
+// invalidResponse 接口返回的内容无法解析
+func invalidResponse(err error) error {
+	return &errorx.ProviderError{Provider: providerName, Message: "invalid response", Err: err}
+}
 
-	// 创建临时文件
-	tmpFile, err := ioutil.TempFile("/tmp", "tempfile")
+// saveFile 下载 url 到本地临时文件，client 为空时使用 fetcher.Default()
+func saveFile(ctx context.Context, client *http.Client, url string, suffix string) (string, error) {
+	f, err := fetcher.ForClient(client).Fetch(ctx, url)
 	if err != nil {
 		return "", err
 	}
-	defer tmpFile.Close()
-
-	// 将HTTP响应的内容写入临时文件
-	_, err = io.Copy(tmpFile, response.Body)
-	if err != nil {
-		return "", errors.New("写入临时文件时出错！")
+	if suffix == "" || suffix == f.Ext() {
+		return f.Path, nil
 	}
 
-	// 重命名临时文件为目标文件名并保存到本地/tmp目录下
-	err = os.Rename(tmpFile.Name(), "/tmp/"+targetName)
-	if err != nil {
-		return "", errors.New("重命名临时文件失败！")
+	targetName := strings.TrimSuffix(f.Path, filepath.Ext(f.Path)) + "." + suffix
+	if err := os.Rename(f.Path, targetName); err != nil {
+		f.Remove()
+		return "", errorx.Wrap(errorx.ErrTempFile, err)
 	}
-	return "/tmp/" + targetName, nil
+	return targetName, nil
 }
 
 // base64编码后进行urlEncode
 func (b *BaiduOcr) getFileContentAsBase64(path string) string {
 	srcByte, err := ioutil.ReadFile(path)
 	if err != nil {
-		fmt.Println(err)
+		logx.Or(b.logger).Warn("baidu read file failed", "path", path, "err", err)
 		return ""
 	}
 	return base64.StdEncoding.EncodeToString(srcByte)
 }
 
 func getSuffix(url string) (string, error) {
-	dotIndex := strings.LastIndex(url, ".")
-	if dotIndex == -1 || dotIndex == len(url)-1 {
-		return "", errors.New("没有文件后缀或者文件名以点号结尾！")
+	return filetype.ExtFromPath(url)
+}
+
+// checkImage 按文件内容识别图片格式，百度只支持 jpg/png/bmp
+func checkImage(t filetype.Type) (string, error) {
+	switch t {
+	case filetype.Jpeg, filetype.Png, filetype.Bmp:
+		return t.Ext, nil
 	}
-	suffix := url[dotIndex+1:]
-	return suffix, nil
+	return "", errorx.New(errorx.ErrUnsupportedFormat, "image must be jpg, png or bmp")
 }
 
 func countSize(filePath string) (int, error) {
 	fileInfo, err := os.Stat(filePath)
 	if err != nil {
-		return 0, errors.New("计算文件大小失败！")
+		return 0, errorx.Wrap(errorx.ErrRead, err)
 	}
 
 	fileSize := int(fileInfo.Size())
 	return fileSize, nil
 }
 
-func countImgSize(url string) (int, error) {
-	// 获取响应
-	resp, err := http.Get(url)
+func countImgSize(ctx context.Context, client *http.Client, url string) (int, filetype.Type, error) {
+	f, err := fetcher.ForClient(client).Fetch(ctx, url)
 	if err != nil {
-		return 0, errors.New("远程获取图片失败！")
+		return 0, filetype.Unknown, errorx.Wrap(errorx.ErrDownload, err)
 	}
-	defer resp.Body.Close()
+	defer f.Remove()
 
-	// 读取body
-	body, err := ioutil.ReadAll(resp.Body)
+	// 获取大小和格式
+	t, err := filetype.DetectFile(f.Path)
 	if err != nil {
-		return 0, errors.New("后去图片大小失败！")
+		return 0, filetype.Unknown, errorx.Wrap(errorx.ErrRead, err)
 	}
-
-	// 获取大小
-	size := len(body)
-	return size, nil
+	return int(f.Size), t, nil
 }
 
 func md5ByString(str string) (string, error) {
@@ -158,19 +171,31 @@ func md5ByString(str string) (string, error) {
 func getPdfNum(filePath string) (int, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
-		return 0, errors.New("无法打开 PDF 文件！")
+		return 0, errorx.Wrap(errorx.ErrRead, err)
 	}
 	defer file.Close()
 	// 创建 PDF reader
 	pdfReader, err := model.NewPdfReader(file)
 	if err != nil {
-		return 0, errors.New("无法创建 PDF reader！")
+		return 0, errorx.Wrap(errorx.ErrParse, err)
 	}
 	// 获取 PDF 文件总页数
 	numPages, err := pdfReader.GetNumPages()
 	if err != nil {
-		return 0, errors.New("无法获取 PDF 文件页数！")
+		return 0, errorx.Wrap(errorx.ErrParse, err)
 	}
 
 	return numPages, nil
 }
+
+// sleepCtx 等待 d，ctx 取消时提前返回
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
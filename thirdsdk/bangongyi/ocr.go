@@ -1,8 +1,12 @@
 package bangongyi
 
 import (
-	"encoding/json"
-	"errors"
+	"context"
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
+	"github.com/comqositi/toolkits/thirdsdk/filetype"
+	"github.com/comqositi/toolkits/thirdsdk/httpx"
+	"github.com/comqositi/toolkits/thirdsdk/logx"
+	"net/http"
 	"os"
 	"strings"
 )
@@ -18,39 +22,91 @@ type InfoResponse struct {
 	Data    []string `json:"data"`
 }
 
+const providerName = "bangongyi"
+
+var (
+	errNotImage      = errorx.New(errorx.ErrUnsupportedFormat, "not an image file")
+	errNotPdf        = errorx.New(errorx.ErrUnsupportedFormat, "not a pdf file")
+	errImageTooLarge = errorx.New(errorx.ErrTooLarge, "image exceeds 10MB")
+	errPdfTooLarge   = errorx.New(errorx.ErrTooLarge, "pdf exceeds 20MB")
+	errTooManyPages  = errorx.New(errorx.ErrTooManyPages, "page count exceeds 200")
+)
+
+// Client 办公易识别客户端
+type Client struct {
+	url        string
+	httpClient *http.Client
+	logger     logx.Logger
+}
+
+// NewClient 创建客户端，url 为办公易接口地址，httpClient 为空时使用 httpx.Default()
+func NewClient(url string, httpClient *http.Client) *Client {
+	return &Client{url: url, httpClient: httpClient}
+}
+
+// WithLogger 返回使用 l 记录日志的客户端副本，l 为空时使用 logx.Default()
+func (c *Client) WithLogger(l logx.Logger) *Client {
+	cp := *c
+	cp.logger = l
+	return &cp
+}
+
+func (c *Client) client() *http.Client {
+	return httpx.Or(c.httpClient)
+}
+
+// ImageToContent 图片地址转文字，url 为办公易接口地址
 func ImageToContent(url string, imageUrl string) (word string, fileSuffix string, FileSize int, err error) {
-	suffix, err := getSuffix(imageUrl)
+	return ImageToContentCtx(context.Background(), url, imageUrl)
+}
+
+// ImageToContentCtx 同 ImageToContent，ctx 取消时中止请求
+func ImageToContentCtx(ctx context.Context, url string, imageUrl string) (word string, fileSuffix string, FileSize int, err error) {
+	return NewClient(url, nil).ImageToContentCtx(ctx, imageUrl)
+}
+
+// ImageToContent 图片地址转文字
+func (c *Client) ImageToContent(imageUrl string) (word string, fileSuffix string, FileSize int, err error) {
+	return c.ImageToContentCtx(context.Background(), imageUrl)
+}
+
+// ImageToContentCtx 同 ImageToContent，ctx 取消时中止请求
+func (c *Client) ImageToContentCtx(ctx context.Context, imageUrl string) (word string, fileSuffix string, FileSize int, err error) {
+	suffix, _ := getSuffix(imageUrl)
+	filePath, err := saveFile(ctx, c.httpClient, imageUrl, suffix)
 	if err != nil {
-		return "", "", 0, errors.New("获取前缀失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrDownload, err)
 	}
+	defer os.Remove(filePath)
 
-	filePath, err := saveFile(imageUrl, suffix)
-	if err != nil {
-		return "", "", 0, errors.New("文件保存在本地失败！")
+	t, err := filetype.DetectFile(filePath)
+	if err != nil || !t.IsImage() {
+		return "", "", 0, errNotImage
 	}
+	suffix = t.Ext
 
 	size, err := countSize(filePath)
 	if err != nil {
-		return "", "", 0, errors.New("计算文件大小失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrRead, err)
 	}
 
 	if size > 1048576*10 {
-		return "", "", 0, errors.New("图片不能大于 10 MB！")
+		return "", "", 0, errImageTooLarge
 	}
 
-	defer os.Remove(filePath)
-
-	body, _ := PostRequest(url, &InfoRequest{
+	body, err := postRequest(ctx, c.client(), logx.Or(c.logger), c.url, &InfoRequest{
 		Url: imageUrl,
 	})
-
-	resBody := InfoResponse{}
-	err = json.Unmarshal(body, &resBody)
 	if err != nil {
-		return "", "", 0, errors.New("解析数据失败！")
+		if ctx.Err() != nil {
+			return "", "", 0, ctx.Err()
+		}
+		return "", "", 0, err
 	}
-	if resBody.Success != true {
-		return "", "", 0, errors.New("解析数据失败！")
+
+	resBody, err := parseInfoResponse(logx.Or(c.logger), body)
+	if err != nil {
+		return "", "", 0, err
 	}
 	for _, v := range resBody.Data {
 		word += v + ","
@@ -60,45 +116,62 @@ func ImageToContent(url string, imageUrl string) (word string, fileSuffix string
 	return word, suffix, size, nil
 }
 
+// PdfToContent pdf地址转文字，pageNum 为读取的页数，最多 200 页
 func PdfToContent(url string, pdfUrl string, pageNum int64) (word string, fileSuffix string, FileSize int, err error) {
+	return PdfToContentCtx(context.Background(), url, pdfUrl, pageNum)
+}
+
+// PdfToContentCtx 同 PdfToContent，ctx 取消时中止请求
+func PdfToContentCtx(ctx context.Context, url string, pdfUrl string, pageNum int64) (word string, fileSuffix string, FileSize int, err error) {
+	return NewClient(url, nil).PdfToContentCtx(ctx, pdfUrl, pageNum)
+}
+
+// PdfToContent pdf地址转文字，pageNum 为读取的页数，最多 200 页
+func (c *Client) PdfToContent(pdfUrl string, pageNum int64) (word string, fileSuffix string, FileSize int, err error) {
+	return c.PdfToContentCtx(context.Background(), pdfUrl, pageNum)
+}
+
+// PdfToContentCtx 同 PdfToContent，ctx 取消时中止请求
+func (c *Client) PdfToContentCtx(ctx context.Context, pdfUrl string, pageNum int64) (word string, fileSuffix string, FileSize int, err error) {
 	if pageNum > 200 {
-		if err != nil {
-			return "", "", 0, errors.New("读取页数不能超过 200 页！")
-		}
+		return "", "", 0, errTooManyPages
 	}
-	suffix, err := getSuffix(pdfUrl)
+	suffix, _ := getSuffix(pdfUrl)
+	filePath, err := saveFile(ctx, c.httpClient, pdfUrl, suffix)
 	if err != nil {
-		return "", "", 0, errors.New("获取前缀失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrDownload, err)
 	}
+	defer os.Remove(filePath)
 
-	filePath, err := saveFile(pdfUrl, suffix)
-	if err != nil {
-		return "", "", 0, errors.New("文件保存在本地失败！")
+	t, err := filetype.DetectFile(filePath)
+	if err != nil || t != filetype.Pdf {
+		return "", "", 0, errNotPdf
 	}
+	suffix = t.Ext
 
 	size, err := countSize(filePath)
 	if err != nil {
-		return "", "", 0, errors.New("计算文件大小失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrRead, err)
 	}
 
 	if size > 1048576*20 {
-		return "", "", 0, errors.New("图片不能大于 20 MB！")
+		return "", "", 0, errPdfTooLarge
 	}
 
-	defer os.Remove(filePath)
-
-	body, _ := PostRequest(url, &InfoRequest{
+	body, err := postRequest(ctx, c.client(), logx.Or(c.logger), c.url, &InfoRequest{
 		Url:     pdfUrl,
 		PageNum: pageNum,
 	})
-
-	resBody := InfoResponse{}
-	err = json.Unmarshal(body, &resBody)
 	if err != nil {
-		return "", "", 0, errors.New("解析数据失败！")
+		if ctx.Err() != nil {
+			return "", "", 0, ctx.Err()
+		}
+		return "", "", 0, err
 	}
-	if resBody.Success != true {
-		return "", "", 0, errors.New("解析数据失败！")
+
+	resBody, err := parseInfoResponse(logx.Or(c.logger), body)
+	if err != nil {
+		return "", "", 0, err
 	}
 	for _, v := range resBody.Data {
 		word += v + ","
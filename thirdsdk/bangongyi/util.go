@@ -2,136 +2,101 @@ package bangongyi
 
 import (
 	"bytes"
-	"crypto/md5"
+	"context"
 	"encoding/json"
-	"errors"
-	"fmt"
-	"github.com/unidoc/unipdf/v3/model"
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
+	"github.com/comqositi/toolkits/thirdsdk/fetcher"
+	"github.com/comqositi/toolkits/thirdsdk/filetype"
+	"github.com/comqositi/toolkits/thirdsdk/httpx"
+	"github.com/comqositi/toolkits/thirdsdk/logx"
 	"io"
-	"io/ioutil"
-	"log"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
-func saveFile(url string, suffix string) (string, error) {
-	byString, err := md5ByString(url)
+// saveFile 下载 url 到本地临时文件，client 为空时使用 fetcher.Default()
+func saveFile(ctx context.Context, client *http.Client, url string, suffix string) (string, error) {
+	f, err := fetcher.ForClient(client).Fetch(ctx, url)
 	if err != nil {
 		return "", err
 	}
-	targetName := "temporary" + byString + "." + suffix
-
-	// 发起GET请求
-	response, err := http.Get(url)
-	if err != nil {
-		return "", err
-	}
-	defer response.Body.Close()
-
-	// 创建临时文件
-	tmpFile, err := ioutil.TempFile("/tmp", "tempfile")
-	if err != nil {
-		return "", err
-	}
-	defer tmpFile.Close()
-
-	// 将HTTP响应的内容写入临时文件
-	_, err = io.Copy(tmpFile, response.Body)
-	if err != nil {
-		return "", errors.New("写入临时文件时出错！")
+	if suffix == "" || suffix == f.Ext() {
+		return f.Path, nil
 	}
 
-	// 重命名临时文件为目标文件名并保存到本地/tmp目录下
-	err = os.Rename(tmpFile.Name(), "/tmp/"+targetName)
-	if err != nil {
-		return "", errors.New("重命名临时文件失败！")
+	targetName := strings.TrimSuffix(f.Path, filepath.Ext(f.Path)) + "." + suffix
+	if err := os.Rename(f.Path, targetName); err != nil {
+		f.Remove()
+		return "", errorx.Wrap(errorx.ErrTempFile, err)
 	}
-	return "/tmp/" + targetName, nil
+	return targetName, nil
 }
 
 func getSuffix(url string) (string, error) {
-	dotIndex := strings.LastIndex(url, ".")
-	if dotIndex == -1 || dotIndex == len(url)-1 {
-		return "", errors.New("没有文件后缀或者文件名以点号结尾！")
-	}
-	suffix := url[dotIndex+1:]
-	return suffix, nil
+	return filetype.ExtFromPath(url)
 }
 
 func countSize(filePath string) (int, error) {
 	fileInfo, err := os.Stat(filePath)
 	if err != nil {
-		return 0, errors.New("计算文件大小失败！")
+		return 0, errorx.Wrap(errorx.ErrRead, err)
 	}
 
 	fileSize := int(fileInfo.Size())
 	return fileSize, nil
 }
 
-func countImgSize(url string) (int, error) {
-	// 获取响应
-	resp, err := http.Get(url)
-	if err != nil {
-		return 0, errors.New("远程获取图片失败！")
-	}
-	defer resp.Body.Close()
-
-	// 读取body
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return 0, errors.New("后去图片大小失败！")
-	}
-
-	// 获取大小
-	size := len(body)
-	return size, nil
+// PostRequest 以 json 方式请求办公易接口
+func PostRequest(url string, reqBody any) (body []byte, err error) {
+	return PostRequestCtx(context.Background(), url, reqBody)
 }
 
-func md5ByString(str string) (string, error) {
-	m := md5.New()
-	_, err := io.WriteString(m, str)
-	if err != nil {
-		return "", err
-	}
-	arr := m.Sum(nil)
-	return fmt.Sprintf("%x", arr), nil
+// PostRequestCtx 同 PostRequest，ctx 取消时中止请求
+func PostRequestCtx(ctx context.Context, url string, reqBody any) (body []byte, err error) {
+	return postRequest(ctx, httpx.Default(), logx.Default(), url, reqBody)
 }
 
-func getPdfNum(filePath string) (int, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return 0, errors.New("无法打开 PDF 文件！")
-	}
-	defer file.Close()
-	// 创建 PDF reader
-	pdfReader, err := model.NewPdfReader(file)
-	if err != nil {
-		return 0, errors.New("无法创建 PDF reader！")
-	}
-	// 获取 PDF 文件总页数
-	numPages, err := pdfReader.GetNumPages()
+func postRequest(ctx context.Context, client *http.Client, log logx.Logger, url string, reqBody any) (body []byte, err error) {
+	sBody, err := json.Marshal(reqBody)
 	if err != nil {
-		return 0, errors.New("无法获取 PDF 文件页数！")
+		return
 	}
-
-	return numPages, nil
-}
-
-func PostRequest(url string, reqBody any) (body []byte, err error) {
-	sBody, err := json.Marshal(reqBody)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(sBody))
 	if err != nil {
 		return
 	}
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(sBody))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
 	if err != nil {
-		log.Fatal(err)
+		err = &errorx.ProviderError{Provider: providerName, Err: logx.RedactError(err)}
+		log.Error("bangongyi request failed", "err", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		log.Error("bangongyi request failed", "status", resp.StatusCode)
+		return nil, &errorx.ProviderError{Provider: providerName, StatusCode: resp.StatusCode, Message: resp.Status}
+	}
 	// 读取响应体
 	body, err = io.ReadAll(resp.Body)
 	if err != nil {
-		return
+		return nil, &errorx.ProviderError{Provider: providerName, StatusCode: resp.StatusCode, Err: err}
 	}
 	return
 }
+
+// parseInfoResponse 解析识别结果，接口返回失败时转为 ProviderError
+func parseInfoResponse(log logx.Logger, body []byte) (*InfoResponse, error) {
+	resBody := &InfoResponse{}
+	if err := json.Unmarshal(body, resBody); err != nil {
+		log.Error("bangongyi parse response failed", "err", err)
+		return nil, &errorx.ProviderError{Provider: providerName, Message: "invalid response", Err: err}
+	}
+	if !resBody.Success {
+		log.Warn("bangongyi recognize failed", "msg", resBody.Msg)
+		return nil, &errorx.ProviderError{Provider: providerName, Message: resBody.Msg}
+	}
+	return resBody, nil
+}
@@ -0,0 +1,241 @@
+// Package charset 识别文本文件的编码并转换为 UTF-8
+package charset
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	xunicode "golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// 可识别的编码，GBK 和 GB2312 均按其超集 GB18030 处理
+const (
+	UTF8     = "utf-8"
+	UTF16LE  = "utf-16le"
+	UTF16BE  = "utf-16be"
+	GB18030  = "gb18030"
+	Big5     = "big5"
+	ShiftJIS = "shift_jis"
+)
+
+// 识别时最多取样的字节数
+const sampleSize = 64 << 10
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// legacy 按统计判断的多字节编码，得分相同时靠前的优先
+var legacy = []struct {
+	name string
+	enc  encoding.Encoding
+}{
+	{GB18030, simplifiedchinese.GB18030},
+	{Big5, traditionalchinese.Big5},
+	{ShiftJIS, japanese.ShiftJIS},
+}
+
+// Detect 识别 data 的编码：先看 BOM，再按零字节分布判断无 BOM 的 UTF-16，
+// 合法的 UTF-8 视为 UTF-8，否则按常用字频率在 GB18030、Big5、Shift-JIS 中选择；
+// 无法识别（如二进制内容）时返回空串
+func Detect(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		return UTF8
+	case bytes.HasPrefix(data, bomUTF16LE):
+		return UTF16LE
+	case bytes.HasPrefix(data, bomUTF16BE):
+		return UTF16BE
+	}
+	if len(data) > sampleSize {
+		data = data[:sampleSize]
+	}
+	if name := detectUTF16(data); name != "" {
+		return name
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return ""
+	}
+	if validUTF8(data) {
+		return UTF8
+	}
+	return detectLegacy(data)
+}
+
+// Decode 识别编码并转换为 UTF-8，去掉 BOM，返回转换后的文本和识别出的编码
+func Decode(data []byte) (text string, name string, err error) {
+	name = Detect(data)
+	if name == UTF8 {
+		if utf8.Valid(data) {
+			return string(bytes.TrimPrefix(data, bomUTF8)), UTF8, nil
+		}
+		// 取样部分是合法的 UTF-8，后面不是，按全文重新判断
+		name = detectLegacy(data)
+	}
+	enc := lookup(name)
+	if enc == nil {
+		return "", "", errorx.ErrEncoding
+	}
+	switch name {
+	case UTF16LE:
+		data = bytes.TrimPrefix(data, bomUTF16LE)
+	case UTF16BE:
+		data = bytes.TrimPrefix(data, bomUTF16BE)
+	}
+	out, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", "", errorx.Wrap(errorx.ErrEncoding, err)
+	}
+	return string(out), name, nil
+}
+
+// lookup 返回 UTF-8 以外的编码，BOM 由调用方去掉
+func lookup(name string) encoding.Encoding {
+	switch name {
+	case UTF16LE:
+		return xunicode.UTF16(xunicode.LittleEndian, xunicode.IgnoreBOM)
+	case UTF16BE:
+		return xunicode.UTF16(xunicode.BigEndian, xunicode.IgnoreBOM)
+	}
+	for _, l := range legacy {
+		if l.name == name {
+			return l.enc
+		}
+	}
+	return nil
+}
+
+// NewReader 按开头一段识别编码，返回转换为 UTF-8 并去掉 BOM 的 reader，用于不便一次读入内存的大文件；
+// 开头为合法 UTF-8 时按 UTF-8 原样输出，后面的非法字节不做转换
+func NewReader(r io.Reader) (io.Reader, string, error) {
+	br := bufio.NewReaderSize(r, sampleSize)
+	head, err := br.Peek(sampleSize)
+	if err != nil && err != io.EOF {
+		return nil, "", errorx.Wrap(errorx.ErrRead, err)
+	}
+	name := Detect(head)
+	switch name {
+	case UTF8:
+		if bytes.HasPrefix(head, bomUTF8) {
+			_, _ = br.Discard(len(bomUTF8))
+		}
+		return br, name, nil
+	case UTF16LE, UTF16BE:
+		if bytes.HasPrefix(head, bomUTF16LE) || bytes.HasPrefix(head, bomUTF16BE) {
+			_, _ = br.Discard(2)
+		}
+	}
+	enc := lookup(name)
+	if enc == nil {
+		return nil, "", errorx.ErrEncoding
+	}
+	return transform.NewReader(br, enc.NewDecoder()), name, nil
+}
+
+// detectUTF16 没有 BOM 的 UTF-16 文本以西文为主时，每两个字节中高位字节多为零
+func detectUTF16(data []byte) string {
+	n := len(data) / 2
+	if n < 2 {
+		return ""
+	}
+	var even, odd int
+	for i := 0; i < n*2; i += 2 {
+		if data[i] == 0 {
+			even++
+		}
+		if data[i+1] == 0 {
+			odd++
+		}
+	}
+	switch {
+	case odd*10 >= n*3 && even*20 < n:
+		return UTF16LE
+	case even*10 >= n*3 && odd*20 < n:
+		return UTF16BE
+	}
+	return ""
+}
+
+// validUTF8 允许结尾处被截断的多字节字符
+func validUTF8(data []byte) bool {
+	for i := 0; i < 3 && len(data) > 0 && !utf8.Valid(data); i++ {
+		data = data[:len(data)-1]
+	}
+	return utf8.Valid(data)
+}
+
+// detectLegacy 用各编码解码取样内容，按常用字、假名和全角标点计分，乱码和控制字符扣分，取得分最高且为正的编码
+func detectLegacy(data []byte) string {
+	// 从第一个非 ASCII 字节开始取样，开头的西文对判断没有帮助
+	for i, c := range data {
+		if c >= 0x80 {
+			data = data[i:]
+			break
+		}
+	}
+	if len(data) > sampleSize {
+		data = data[:sampleSize]
+	}
+	best, bestScore := "", 0
+	for _, l := range legacy {
+		out, err := l.enc.NewDecoder().Bytes(data)
+		if err != nil {
+			continue
+		}
+		if s := score(out); s > bestScore {
+			best, bestScore = l.name, s
+		}
+	}
+	return best
+}
+
+func score(text []byte) int {
+	s := 0
+	for len(text) > 0 {
+		r, size := utf8.DecodeRune(text)
+		text = text[size:]
+		switch {
+		case r == '\t', r == '\n', r == '\r', r == '\f':
+		case r < 0x20 || r == 0x7F:
+			s -= 5
+		case r < 0x80:
+		case r == utf8.RuneError:
+			s -= 20
+		case commonHan[r]:
+			s += 3
+		case unicode.Is(unicode.Han, r):
+			s++
+		case r >= 0x3040 && r <= 0x30FF:
+			// 平假名和片假名
+			s += 2
+		case r >= 0x3000 && r <= 0x303F, r >= 0xFF01 && r <= 0xFF5E:
+			// 中日文标点和全角字符
+			s += 2
+		default:
+			s -= 2
+		}
+	}
+	return s
+}
+
+// commonHan 中文常用字的简体和繁体写法，覆盖日常文本中的大部分汉字
+var commonHan = func() map[rune]bool {
+	const chars = "的一是在不了有和人这中大为上个国我以要他时来用们生到作地于出就分对成会可主发年动同工也能下过子说产种面而方后多定行学法所民得经十三之进着等部度家电力里如水化高自二理起小物现实加量都两体制机当使点从业本去把性好应开它合还因由其些然前外天政四日那社义事平形相全表间样与关各重新线内数正心反你明看原又么利比或但质气第向道命此变条只没结解问意建月公无系军很情者最立代想已通并提直题党程展五果料象员革位入常文总次品式活设及管特件长求老头基资边流路级少图山统接知较将组见计别她手角期根论运农指几九区强放决西被干做必战先回则任取据处府研" +
+		"這為個國們來時說對會發動過產種後學經進著電裡現實兩體機當從業還開與關間樣數氣變條沒結問軍題黨員總長頭資邊級圖統較將組見計別論運農幾區強決幹戰則據處義從應麼點線條號書請認導車門讓記給東務愛師報聽讀寫買賣錢話語親覺萬難辦單課藝術醫藥價格標準設計環境節題議價隨調查證據險據權"
+	m := make(map[rune]bool, len(chars)/3)
+	for _, r := range chars {
+		m[r] = true
+	}
+	return m
+}()
@@ -0,0 +1,104 @@
+package errorx
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 错误类别，使用 errors.Is 判断；展示给用户的文字通过 Message 获取
+var (
+	ErrUnsupportedFormat = errors.New("unsupported format")
+	ErrTooLarge          = errors.New("file too large")
+	ErrTooManyPages      = errors.New("too many pages")
+	ErrPageRange         = errors.New("page out of range")
+	ErrEncrypted         = errors.New("file is encrypted")
+	ErrEncoding          = errors.New("invalid text encoding")
+	ErrInvalidInput      = errors.New("invalid input")
+	ErrEmptyInput        = errors.New("empty input")
+	ErrEmptyResult       = errors.New("empty result")
+	ErrBlocked           = errors.New("address not allowed")
+	ErrDownload          = errors.New("download failed")
+	ErrRead              = errors.New("read file failed")
+	ErrParse             = errors.New("parse file failed")
+	ErrTempFile          = errors.New("temporary file failed")
+	ErrNotConfigured     = errors.New("provider not configured")
+	ErrProvider          = errors.New("provider request failed")
+)
+
+// Error 带有类别和原因的错误，errors.Is 同时匹配 Kind 和 Err
+type Error struct {
+	Kind error // 错误类别，为本包定义的 Err 变量
+	Err  error // 原因
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Kind.Error()
+	}
+	return e.Kind.Error() + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// New 创建 kind 类别下更具体的错误，可用作包级变量
+func New(kind error, text string) error {
+	return &Error{Kind: kind, Err: errors.New(text)}
+}
+
+// Errorf 同 New，按格式生成原因
+func Errorf(kind error, format string, args ...any) error {
+	return &Error{Kind: kind, Err: fmt.Errorf(format, args...)}
+}
+
+// Wrap 将 cause 归入 kind 类别；cause 为空时返回 kind，已属于 kind 时原样返回
+func Wrap(kind error, cause error) error {
+	if cause == nil {
+		return kind
+	}
+	if errors.Is(cause, kind) {
+		return cause
+	}
+	return &Error{Kind: kind, Err: cause}
+}
+
+// ProviderError 第三方识别服务返回的错误，errors.Is(err, ErrProvider) 为 true
+type ProviderError struct {
+	Provider   string // 服务名称，如 baidu、bangongyi
+	StatusCode int    // HTTP 状态码，未收到响应时为 0
+	Code       string // 服务返回的错误码
+	Message    string // 服务返回的错误信息
+	Err        error  // 原因
+}
+
+func (e *ProviderError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Provider)
+	if e.StatusCode != 0 {
+		b.WriteString(": status " + strconv.Itoa(e.StatusCode))
+	}
+	if e.Code != "" {
+		b.WriteString(": code " + e.Code)
+	}
+	if e.Message != "" {
+		b.WriteString(": " + e.Message)
+	}
+	if e.Err != nil {
+		b.WriteString(": " + e.Err.Error())
+	}
+	return b.String()
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ProviderError) Is(target error) bool {
+	return target == ErrProvider
+}
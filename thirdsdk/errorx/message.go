@@ -0,0 +1,112 @@
+package errorx
+
+import (
+	"errors"
+	"sync"
+)
+
+// 内置的提示语言
+const (
+	Zh = "zh"
+	En = "en"
+)
+
+// kinds 按优先级排列，包装了多个类别时取最具体的一个
+var kinds = []error{
+	ErrTooLarge,
+	ErrTooManyPages,
+	ErrPageRange,
+	ErrEncrypted,
+	ErrEncoding,
+	ErrBlocked,
+	ErrUnsupportedFormat,
+	ErrInvalidInput,
+	ErrEmptyInput,
+	ErrEmptyResult,
+	ErrNotConfigured,
+	ErrProvider,
+	ErrDownload,
+	ErrParse,
+	ErrRead,
+	ErrTempFile,
+}
+
+var (
+	mu       sync.RWMutex
+	messages = map[string]map[error]string{
+		Zh: {
+			ErrUnsupportedFormat: "不支持的文件格式！",
+			ErrTooLarge:          "文件超过大小限制！",
+			ErrTooManyPages:      "文件页数超过限制！",
+			ErrPageRange:         "页码超出范围！",
+			ErrEncrypted:         "文件已加密！",
+			ErrEncoding:          "无法识别文件编码！",
+			ErrInvalidInput:      "参数不正确！",
+			ErrEmptyInput:        "识别内容不能为空！",
+			ErrEmptyResult:       "识别结果为空！",
+			ErrBlocked:           "禁止访问该地址！",
+			ErrDownload:          "下载文件失败！",
+			ErrRead:              "读取文件失败！",
+			ErrParse:             "解析文件失败！",
+			ErrTempFile:          "写入临时文件时出错！",
+			ErrNotConfigured:     "未配置识别服务！",
+			ErrProvider:          "请求识别接口失败！",
+			nil:                  "处理失败！",
+		},
+		En: {
+			ErrUnsupportedFormat: "Unsupported file format.",
+			ErrTooLarge:          "The file exceeds the size limit.",
+			ErrTooManyPages:      "The file exceeds the page limit.",
+			ErrPageRange:         "Page out of range.",
+			ErrEncrypted:         "The file is encrypted.",
+			ErrEncoding:          "Unrecognized text encoding.",
+			ErrInvalidInput:      "Invalid input.",
+			ErrEmptyInput:        "Nothing to recognize.",
+			ErrEmptyResult:       "Nothing was recognized.",
+			ErrBlocked:           "Access to this address is not allowed.",
+			ErrDownload:          "Failed to download the file.",
+			ErrRead:              "Failed to read the file.",
+			ErrParse:             "Failed to parse the file.",
+			ErrTempFile:          "Failed to write a temporary file.",
+			ErrNotConfigured:     "No recognition provider is configured.",
+			ErrProvider:          "The recognition service request failed.",
+			nil:                  "Something went wrong.",
+		},
+	}
+)
+
+// Message 返回适合展示给用户的中文提示，err 为空时返回空串
+func Message(err error) string {
+	return MessageIn(Zh, err)
+}
+
+// MessageIn 返回 lang 语言的提示，该语言未配置对应提示时使用中文
+func MessageIn(lang string, err error) string {
+	if err == nil {
+		return ""
+	}
+	var kind error
+	for _, k := range kinds {
+		if errors.Is(err, k) {
+			kind = k
+			break
+		}
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+	if msg, ok := messages[lang][kind]; ok {
+		return msg
+	}
+	return messages[Zh][kind]
+}
+
+// SetMessage 设置 lang 语言下 kind 类别的提示，kind 为空时设置默认提示
+func SetMessage(lang string, kind error, msg string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if messages[lang] == nil {
+		messages[lang] = make(map[error]string)
+	}
+	messages[lang][kind] = msg
+}
@@ -0,0 +1,298 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
+	"github.com/comqositi/toolkits/thirdsdk/httpx"
+)
+
+var (
+	ErrScheme           = errorx.New(errorx.ErrInvalidInput, "only http and https urls are allowed")
+	ErrBlocked          = errorx.New(errorx.ErrBlocked, "private address")
+	ErrStatus           = errorx.New(errorx.ErrDownload, "unexpected status")
+	ErrContentType      = errorx.New(errorx.ErrUnsupportedFormat, "content type not allowed")
+	ErrTooLarge         = errorx.New(errorx.ErrTooLarge, "response exceeds size limit")
+	ErrTooManyRedirects = errorx.New(errorx.ErrDownload, "too many redirects")
+)
+
+const (
+	// DefaultMaxBytes 默认最大下载字节数
+	DefaultMaxBytes int64 = 100 << 20
+	// DefaultMaxRedirects 默认最多跟随的重定向次数
+	DefaultMaxRedirects = 5
+)
+
+// Options 下载限制
+type Options struct {
+	MaxBytes     int64          // 最大下载字节数，0 使用 DefaultMaxBytes，小于 0 不限制
+	MaxRedirects int            // 最多跟随的重定向次数，0 使用 DefaultMaxRedirects，小于 0 不跟随
+	ContentTypes []string       // 允许的 Content-Type，支持 image/* 形式的通配，为空不检查
+	AllowPrivate bool           // 允许访问内网、回环和链路本地地址
+	AllowHosts   []string       // 不做地址检查的主机名
+	AllowNets    []netip.Prefix // 不做地址检查的网段
+	// Client 为空时使用内置客户端，在建立连接时检查实际连接的 ip；
+	// 指定 Client 时只能在请求和重定向前解析主机名检查，无法防御 dns 重绑定
+	Client *http.Client
+}
+
+// File 下载到本地的文件
+type File struct {
+	Path        string // 本地临时文件路径，用完后调用 Remove 删除
+	Name        string // 文件名，优先取 Content-Disposition，其次为最终地址中的文件名
+	ContentType string // 响应的 Content-Type
+	Size        int64  // 文件大小
+}
+
+// Ext 返回文件名中的后缀，不含点号
+func (f *File) Ext() string {
+	return strings.TrimPrefix(strings.ToLower(path.Ext(f.Name)), ".")
+}
+
+// Remove 删除本地临时文件
+func (f *File) Remove() error {
+	return os.Remove(f.Path)
+}
+
+// Fetcher 远程文件下载器，可并发使用
+type Fetcher struct {
+	opts   Options
+	client *http.Client
+}
+
+var defaultFetcher = New(Options{})
+
+// Default 返回使用默认限制的下载器
+func Default() *Fetcher {
+	return defaultFetcher
+}
+
+// ForClient 返回使用 c 发送请求、其余为默认限制的下载器，c 为空时返回 Default()
+func ForClient(c *http.Client) *Fetcher {
+	if c == nil {
+		return defaultFetcher
+	}
+	return New(Options{Client: c})
+}
+
+// New 创建下载器
+func New(opts Options) *Fetcher {
+	if opts.MaxBytes == 0 {
+		opts.MaxBytes = DefaultMaxBytes
+	}
+	if opts.MaxRedirects == 0 {
+		opts.MaxRedirects = DefaultMaxRedirects
+	}
+
+	f := &Fetcher{opts: opts}
+	var client http.Client
+	if opts.Client != nil {
+		client = *opts.Client
+	} else {
+		client = http.Client{Timeout: httpx.DefaultTimeout, Transport: f.transport()}
+	}
+	client.CheckRedirect = f.checkRedirect
+	f.client = &client
+	return f
+}
+
+// Fetch 使用默认下载器下载 rawUrl
+func Fetch(ctx context.Context, rawUrl string) (*File, error) {
+	return defaultFetcher.Fetch(ctx, rawUrl)
+}
+
+// Fetch 下载 rawUrl 到本地临时文件，边下载边检查大小
+func (f *Fetcher) Fetch(ctx context.Context, rawUrl string) (*File, error) {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.checkUrl(ctx, u); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, errorx.Wrap(errorx.ErrDownload, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%w: %s", ErrStatus, resp.Status)
+	}
+	if f.opts.MaxBytes > 0 && resp.ContentLength > f.opts.MaxBytes {
+		return nil, ErrTooLarge
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if !f.allowedType(contentType) {
+		return nil, fmt.Errorf("%w: %s", ErrContentType, contentType)
+	}
+
+	file := &File{Name: fileName(resp), ContentType: contentType}
+	pattern := "fetch-*"
+	if ext := file.Ext(); ext != "" {
+		pattern += "." + ext
+	}
+	tmpFile, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return nil, err
+	}
+	file.Path = tmpFile.Name()
+
+	var body io.Reader = resp.Body
+	if f.opts.MaxBytes > 0 {
+		body = io.LimitReader(resp.Body, f.opts.MaxBytes+1)
+	}
+	file.Size, err = io.Copy(tmpFile, body)
+	if cerr := tmpFile.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && f.opts.MaxBytes > 0 && file.Size > f.opts.MaxBytes {
+		err = ErrTooLarge
+	}
+	if err != nil {
+		os.Remove(file.Path)
+		return nil, err
+	}
+	return file, nil
+}
+
+func (f *Fetcher) checkRedirect(req *http.Request, via []*http.Request) error {
+	if f.opts.MaxRedirects < 0 || len(via) > f.opts.MaxRedirects {
+		return ErrTooManyRedirects
+	}
+	return f.checkUrl(req.Context(), req.URL)
+}
+
+// checkUrl 检查协议，并解析主机名确认不是内网地址
+func (f *Fetcher) checkUrl(ctx context.Context, u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ErrScheme
+	}
+	host := u.Hostname()
+	if f.opts.AllowPrivate || f.allowedHost(host) {
+		return nil
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return f.checkAddr(addr)
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if err := f.checkAddr(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transport 内置客户端的 Transport，在连接时检查实际的 ip；不走代理，避免只检查到代理地址
+func (f *Fetcher) transport() *http.Transport {
+	t := httpx.NewTransport()
+	t.Proxy = nil
+	dialer := &net.Dialer{Timeout: httpx.DialTimeout, KeepAlive: httpx.KeepAlive}
+	guarded := &net.Dialer{Timeout: httpx.DialTimeout, KeepAlive: httpx.KeepAlive, Control: f.control}
+	t.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		if f.opts.AllowPrivate || f.allowedHost(host) {
+			return dialer.DialContext(ctx, network, address)
+		}
+		return guarded.DialContext(ctx, network, address)
+	}
+	return t
+}
+
+func (f *Fetcher) control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	return f.checkAddr(addr)
+}
+
+func (f *Fetcher) checkAddr(addr netip.Addr) error {
+	addr = addr.Unmap()
+	for _, p := range f.opts.AllowNets {
+		if p.Contains(addr) {
+			return nil
+		}
+	}
+	if isPrivate(addr) {
+		return fmt.Errorf("%w: %s", ErrBlocked, addr)
+	}
+	return nil
+}
+
+func (f *Fetcher) allowedHost(host string) bool {
+	for _, h := range f.opts.AllowHosts {
+		if strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Fetcher) allowedType(contentType string) bool {
+	if len(f.opts.ContentTypes) == 0 {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range f.opts.ContentTypes {
+		allowed = strings.ToLower(allowed)
+		if allowed == mediaType || strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(allowed, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// 运营商级 nat 地址 100.64.0.0/10
+var sharedNet = netip.MustParsePrefix("100.64.0.0/10")
+
+func isPrivate(addr netip.Addr) bool {
+	return addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsUnspecified() || sharedNet.Contains(addr) ||
+		addr.Is4() && addr.As4()[0] == 0
+}
+
+// fileName 取 Content-Disposition 中的文件名，没有时取最终地址中的文件名
+func fileName(resp *http.Response) string {
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		if name := filepath.Base(strings.ReplaceAll(params["filename"], "\\", "/")); name != "." && name != "/" {
+			return name
+		}
+	}
+	name := path.Base(resp.Request.URL.Path)
+	if name == "." || name == "/" {
+		return ""
+	}
+	return name
+}
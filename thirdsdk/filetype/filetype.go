@@ -0,0 +1,235 @@
+package filetype
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/comqositi/toolkits/thirdsdk/charset"
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
+)
+
+// Type 文件格式，Ext 为不带点号的规范后缀
+type Type struct {
+	Ext  string `json:"ext"`
+	Mime string `json:"mime"`
+}
+
+var (
+	Unknown = Type{}
+
+	Pdf  = Type{Ext: "pdf", Mime: "application/pdf"}
+	Docx = Type{Ext: "docx", Mime: "application/vnd.openxmlformats-officedocument.wordprocessingml.document"}
+	Xlsx = Type{Ext: "xlsx", Mime: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"}
+	Pptx = Type{Ext: "pptx", Mime: "application/vnd.openxmlformats-officedocument.presentationml.presentation"}
+	Doc  = Type{Ext: "doc", Mime: "application/msword"}
+	Xls  = Type{Ext: "xls", Mime: "application/vnd.ms-excel"}
+	Ppt  = Type{Ext: "ppt", Mime: "application/vnd.ms-powerpoint"}
+	Ole2 = Type{Ext: "ole2", Mime: "application/x-ole-storage"}
+	Zip  = Type{Ext: "zip", Mime: "application/zip"}
+	Png  = Type{Ext: "png", Mime: "image/png"}
+	Jpeg = Type{Ext: "jpg", Mime: "image/jpeg"}
+	Gif  = Type{Ext: "gif", Mime: "image/gif"}
+	Bmp  = Type{Ext: "bmp", Mime: "image/bmp"}
+	Tiff = Type{Ext: "tiff", Mime: "image/tiff"}
+	Webp = Type{Ext: "webp", Mime: "image/webp"}
+	Txt  = Type{Ext: "txt", Mime: "text/plain"}
+	Csv  = Type{Ext: "csv", Mime: "text/csv"}
+	Tsv  = Type{Ext: "tsv", Mime: "text/tab-separated-values"}
+)
+
+const (
+	// 嗅探时读取的文件头长度
+	headerSize = 8192
+)
+
+// ErrNoExt 文件名中没有后缀
+var ErrNoExt = errorx.New(errorx.ErrUnsupportedFormat, "missing file extension")
+
+var ole2Magic = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// IsImage 是否为图片格式
+func (t Type) IsImage() bool {
+	return strings.HasPrefix(t.Mime, "image/")
+}
+
+// IsUnknown 是否未识别
+func (t Type) IsUnknown() bool {
+	return t.Ext == ""
+}
+
+// Detect 根据文件内容识别格式，ZIP 和 OLE2 容器会进一步检查内部结构
+func Detect(r io.ReaderAt, size int64) (Type, error) {
+	n := int64(headerSize)
+	if size < n {
+		n = size
+	}
+	head := make([]byte, n)
+	if _, err := r.ReadAt(head, 0); err != nil && err != io.EOF {
+		return Unknown, err
+	}
+
+	switch {
+	case bytes.HasPrefix(head, []byte("%PDF-")):
+		return Pdf, nil
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")):
+		return detectZip(r, size), nil
+	case bytes.HasPrefix(head, ole2Magic):
+		return detectOle2(r, size), nil
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		return Png, nil
+	case bytes.HasPrefix(head, []byte{0xFF, 0xD8, 0xFF}):
+		return Jpeg, nil
+	case bytes.HasPrefix(head, []byte("GIF87a")), bytes.HasPrefix(head, []byte("GIF89a")):
+		return Gif, nil
+	case isBmp(head):
+		return Bmp, nil
+	case bytes.HasPrefix(head, []byte("II*\x00")), bytes.HasPrefix(head, []byte("MM\x00*")):
+		return Tiff, nil
+	case len(head) >= 12 && bytes.HasPrefix(head, []byte("RIFF")) && string(head[8:12]) == "WEBP":
+		return Webp, nil
+	}
+
+	if isText(head) {
+		return Txt, nil
+	}
+	return Unknown, nil
+}
+
+// DetectBytes 根据内存中的文件内容识别格式
+func DetectBytes(data []byte) Type {
+	t, _ := Detect(bytes.NewReader(data), int64(len(data)))
+	return t
+}
+
+// DetectFile 根据本地文件内容识别格式
+func DetectFile(filePath string) (Type, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return Unknown, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return Unknown, err
+	}
+	return Detect(f, info.Size())
+}
+
+// ByExt 根据后缀返回已知格式
+func ByExt(ext string) Type {
+	switch strings.ToLower(strings.TrimPrefix(ext, ".")) {
+	case "pdf":
+		return Pdf
+	case "docx":
+		return Docx
+	case "xlsx":
+		return Xlsx
+	case "pptx":
+		return Pptx
+	case "doc":
+		return Doc
+	case "xls":
+		return Xls
+	case "ppt":
+		return Ppt
+	case "zip":
+		return Zip
+	case "png":
+		return Png
+	case "jpg", "jpeg":
+		return Jpeg
+	case "gif":
+		return Gif
+	case "bmp":
+		return Bmp
+	case "tif", "tiff":
+		return Tiff
+	case "webp":
+		return Webp
+	case "txt":
+		return Txt
+	case "csv":
+		return Csv
+	case "tsv":
+		return Tsv
+	}
+	return Unknown
+}
+
+// ByMime 根据 mime 类型返回已知格式，忽略参数部分
+func ByMime(mime string) Type {
+	if i := strings.IndexByte(mime, ';'); i >= 0 {
+		mime = mime[:i]
+	}
+	mime = strings.ToLower(strings.TrimSpace(mime))
+	if mime == "" {
+		return Unknown
+	}
+	for _, t := range []Type{Pdf, Docx, Xlsx, Pptx, Doc, Xls, Ppt, Ole2, Zip, Png, Jpeg, Gif, Bmp, Tiff, Webp, Txt, Csv, Tsv} {
+		if t.Mime == mime {
+			return t
+		}
+	}
+	if mime == "image/jpg" {
+		return Jpeg
+	}
+	return Unknown
+}
+
+// ExtFromPath 从本地路径或 url 中取后缀，忽略查询参数和锚点
+func ExtFromPath(p string) (string, error) {
+	if u, err := url.Parse(p); err == nil && u.Scheme != "" && u.Host != "" {
+		p = u.Path
+	} else if i := strings.IndexAny(p, "?#"); i >= 0 {
+		p = p[:i]
+	}
+	ext := strings.TrimPrefix(path.Ext(p), ".")
+	if ext == "" {
+		return "", ErrNoExt
+	}
+	return strings.ToLower(ext), nil
+}
+
+func detectZip(r io.ReaderAt, size int64) Type {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return Zip
+	}
+	for _, f := range zr.File {
+		switch {
+		case strings.HasPrefix(f.Name, "word/"):
+			return Docx
+		case strings.HasPrefix(f.Name, "xl/"):
+			return Xlsx
+		case strings.HasPrefix(f.Name, "ppt/"):
+			return Pptx
+		}
+	}
+	return Zip
+}
+
+// isBmp 检查 BM 标识、保留字节为 0 以及 DIB 头长度，避免把以 BM 开头的文本识别为图片
+func isBmp(head []byte) bool {
+	if len(head) < 18 || !bytes.HasPrefix(head, []byte("BM")) {
+		return false
+	}
+	if binary.LittleEndian.Uint32(head[6:]) != 0 {
+		return false
+	}
+	switch binary.LittleEndian.Uint32(head[14:]) {
+	case 12, 40, 52, 56, 64, 108, 124:
+		return true
+	}
+	return false
+}
+
+// isText 能识别出编码的内容视为文本，包括 UTF-8、UTF-16 和 GB18030 等
+func isText(head []byte) bool {
+	return len(head) > 0 && charset.Detect(head) != ""
+}
@@ -0,0 +1,24 @@
+package filetype
+
+import (
+	"io"
+
+	"github.com/comqositi/toolkits/thirdsdk/internal/ole2"
+)
+
+// detectOle2 按根存储下的流名称识别 doc/xls/ppt，嵌入对象位于子存储中，不参与判断
+func detectOle2(r io.ReaderAt, size int64) Type {
+	f, err := ole2.Open(r, size)
+	if err != nil {
+		return Ole2
+	}
+	switch {
+	case f.HasStream("WordDocument"):
+		return Doc
+	case f.HasStream("Workbook"), f.HasStream("Book"):
+		return Xls
+	case f.HasStream("PowerPoint Document"):
+		return Ppt
+	}
+	return Ole2
+}
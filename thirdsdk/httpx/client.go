@@ -0,0 +1,69 @@
+package httpx
+
+import (
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultTimeout 默认的整体请求超时，包含下载响应体的时间
+	DefaultTimeout = 2 * time.Minute
+	// DialTimeout 建立连接超时
+	DialTimeout = 10 * time.Second
+	// KeepAlive tcp 保活间隔
+	KeepAlive = 30 * time.Second
+)
+
+var defaultClient atomic.Pointer[http.Client]
+
+func init() {
+	defaultClient.Store(NewClient(DefaultTimeout, nil))
+}
+
+// NewTransport 返回带连接、握手和响应头超时的 Transport，代理读取环境变量
+func NewTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   DialTimeout,
+			KeepAlive: KeepAlive,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// NewClient 创建客户端，timeout 为 0 表示不限制整体超时，rt 为空时使用 NewTransport
+func NewClient(timeout time.Duration, rt http.RoundTripper) *http.Client {
+	if rt == nil {
+		rt = NewTransport()
+	}
+	return &http.Client{Timeout: timeout, Transport: rt}
+}
+
+// Default 返回默认客户端
+func Default() *http.Client {
+	return defaultClient.Load()
+}
+
+// SetDefault 替换默认客户端，影响所有未单独指定客户端的调用；传入空值恢复内置默认值
+func SetDefault(c *http.Client) {
+	if c == nil {
+		c = NewClient(DefaultTimeout, nil)
+	}
+	defaultClient.Store(c)
+}
+
+// Or 返回 c，c 为空时返回默认客户端
+func Or(c *http.Client) *http.Client {
+	if c != nil {
+		return c
+	}
+	return Default()
+}
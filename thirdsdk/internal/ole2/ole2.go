@@ -0,0 +1,316 @@
+// Package ole2 读取 OLE2（复合文档）容器，供格式识别和 Office 97-2003 的 doc/xls/ppt 解析共用。
+// 文件内容不可信，FAT 项数、扇区链和目录遍历都以文件实际的扇区数为上限，成环的链直接报错
+package ole2
+
+import (
+	"encoding/binary"
+	"io"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
+)
+
+const (
+	// 大于该值的扇区号为 FREESECT/ENDOFCHAIN 等特殊标记
+	maxRegSect = 0xFFFFFFFA
+	// 目录项中表示“无”的编号
+	noStream = 0xFFFFFFFF
+	dirSize  = 128
+
+	typeStream = 2
+	typeRoot   = 5
+)
+
+// ErrFormat 不是合法的复合文档
+var ErrFormat = errorx.New(errorx.ErrParse, "not an ole2 compound file")
+
+type entry struct {
+	name  string
+	typ   byte
+	left  uint32
+	right uint32
+	child uint32
+	start uint32
+	size  uint64
+}
+
+// File 打开的复合文档，只暴露根存储下一层的流，嵌入对象位于子存储中，不参与查找
+type File struct {
+	r          io.ReaderAt
+	size       int64
+	sectorSize int
+	miniSize   int
+	miniCutoff uint64
+	// sectors 文件中的扇区数，同时作为 FAT 项数和链长的上限
+	sectors int
+	fat     []uint32
+	entries []entry
+	// root 根存储下一层的目录项，键为小写名称
+	root map[string]int
+
+	miniFatStart uint32
+	miniLoaded   bool
+	miniFat      []uint32
+	miniStream   []byte
+}
+
+// Open 读取文件头、FAT 和目录；小流所需的 miniFAT 和根存储数据在首次读取小流时加载
+func Open(r io.ReaderAt, size int64) (*File, error) {
+	header := make([]byte, 512)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, ErrFormat
+	}
+	if string(header[:8]) != "\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1" {
+		return nil, ErrFormat
+	}
+
+	// 版本 3 使用 512 字节扇区，版本 4 使用 4096 字节扇区，字节序标记固定为 0xFFFE
+	major := binary.LittleEndian.Uint16(header[0x1A:])
+	sectorShift := binary.LittleEndian.Uint16(header[0x1E:])
+	miniShift := binary.LittleEndian.Uint16(header[0x20:])
+	if binary.LittleEndian.Uint16(header[0x1C:]) != 0xFFFE || miniShift != 6 ||
+		!(major == 3 && sectorShift == 9 || major == 4 && sectorShift == 12) {
+		return nil, ErrFormat
+	}
+	// 规范规定小流阈值固定为 4096
+	if binary.LittleEndian.Uint32(header[0x38:]) != 4096 {
+		return nil, ErrFormat
+	}
+	f := &File{
+		r:            r,
+		size:         size,
+		sectorSize:   1 << sectorShift,
+		miniSize:     1 << miniShift,
+		miniCutoff:   4096,
+		miniFatStart: binary.LittleEndian.Uint32(header[0x3C:]),
+	}
+	// 第一个扇区之前是文件头，最后一个扇区可以不完整
+	f.sectors = int((size - 1) / int64(f.sectorSize))
+	if f.sectors <= 0 {
+		return nil, ErrFormat
+	}
+
+	if err := f.readFat(header); err != nil {
+		return nil, err
+	}
+
+	dirData, err := f.readChain(binary.LittleEndian.Uint32(header[0x30:]), 0)
+	if err != nil {
+		return nil, err
+	}
+	for off := 0; off+dirSize <= len(dirData); off += dirSize {
+		f.entries = append(f.entries, parseEntry(dirData[off:off+dirSize], f.sectorSize == 512))
+	}
+	if len(f.entries) == 0 || f.entries[0].typ != typeRoot {
+		return nil, ErrFormat
+	}
+	f.readRoot()
+	return f, nil
+}
+
+func (f *File) readFat(header []byte) error {
+	numFat := int(binary.LittleEndian.Uint32(header[0x2C:]))
+	difatStart := binary.LittleEndian.Uint32(header[0x44:])
+
+	// DIFAT 和 FAT 扇区都不能重复出现，否则成环的 DIFAT 链会把同一 FAT 扇区重复计入
+	seen := make(map[uint32]bool)
+	fatSectors := bytesToUint32s(header[0x4C:512])
+	perDifat := f.sectorSize/4 - 1
+	for sect := difatStart; sect <= maxRegSect && len(fatSectors) < numFat; {
+		if seen[sect] {
+			return ErrFormat
+		}
+		seen[sect] = true
+		data, err := f.readSector(sect)
+		if err != nil {
+			return err
+		}
+		ids := bytesToUint32s(data)
+		fatSectors = append(fatSectors, ids[:perDifat]...)
+		sect = ids[perDifat]
+	}
+
+	for i, sect := range fatSectors {
+		if i >= numFat || sect > maxRegSect || len(f.fat) >= f.sectors {
+			break
+		}
+		if seen[sect] {
+			return ErrFormat
+		}
+		seen[sect] = true
+		data, err := f.readSector(sect)
+		if err != nil {
+			return err
+		}
+		f.fat = append(f.fat, bytesToUint32s(data)...)
+	}
+	if len(f.fat) == 0 {
+		return ErrFormat
+	}
+	// FAT 只需覆盖文件中的扇区
+	if len(f.fat) > f.sectors {
+		f.fat = f.fat[:f.sectors]
+	}
+	return nil
+}
+
+// readRoot 根存储的子目录项组成一棵红黑树，按左右兄弟遍历；visited 防止环
+func (f *File) readRoot() {
+	f.root = make(map[string]int)
+	stack := []uint32{f.entries[0].child}
+	visited := make([]bool, len(f.entries))
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id == noStream || int(id) >= len(f.entries) || visited[id] {
+			continue
+		}
+		visited[id] = true
+		e := f.entries[id]
+		if _, ok := f.root[strings.ToLower(e.name)]; !ok {
+			f.root[strings.ToLower(e.name)] = int(id)
+		}
+		stack = append(stack, e.left, e.right)
+	}
+}
+
+func (f *File) readSector(sect uint32) ([]byte, error) {
+	if int64(sect) >= int64(f.sectors) {
+		return nil, ErrFormat
+	}
+	buf := make([]byte, f.sectorSize)
+	n, err := f.r.ReadAt(buf, int64(sect+1)*int64(f.sectorSize))
+	if err != nil && !(err == io.EOF && n > 0) {
+		return nil, ErrFormat
+	}
+	return buf, nil
+}
+
+// readChain 按 FAT 链读取扇区，size 为 0 时读取整条链；链中的扇区不能重复，因此长度不超过文件的扇区数
+func (f *File) readChain(start uint32, size uint64) ([]byte, error) {
+	if size > uint64(f.size) {
+		return nil, ErrFormat
+	}
+	var data []byte
+	visited := make([]bool, len(f.fat))
+	for sect := start; sect <= maxRegSect; {
+		if int(sect) >= len(f.fat) || visited[sect] {
+			return nil, ErrFormat
+		}
+		visited[sect] = true
+		buf, err := f.readSector(sect)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, buf...)
+		if size > 0 && uint64(len(data)) >= size {
+			break
+		}
+		sect = f.fat[sect]
+	}
+	if size > 0 {
+		if uint64(len(data)) < size {
+			return nil, ErrFormat
+		}
+		data = data[:size]
+	}
+	return data, nil
+}
+
+// loadMini 读取 miniFAT 和根存储中保存小流的数据
+func (f *File) loadMini() error {
+	if f.miniLoaded {
+		return nil
+	}
+	miniFatData, err := f.readChain(f.miniFatStart, 0)
+	if err != nil {
+		return err
+	}
+	root := f.entries[0]
+	var miniStream []byte
+	if root.size > 0 {
+		if miniStream, err = f.readChain(root.start, root.size); err != nil {
+			return err
+		}
+	}
+	f.miniFat, f.miniStream, f.miniLoaded = bytesToUint32s(miniFatData), miniStream, true
+	return nil
+}
+
+func (f *File) readMiniChain(start uint32, size uint64) ([]byte, error) {
+	// 小流的数据都在 miniStream 中，size 不可能超过它
+	if size > uint64(len(f.miniStream)) {
+		return nil, ErrFormat
+	}
+	data := make([]byte, 0, size)
+	visited := make([]bool, len(f.miniFat))
+	for sect := start; sect <= maxRegSect && uint64(len(data)) < size; {
+		off := int(sect) * f.miniSize
+		if int(sect) >= len(f.miniFat) || visited[sect] || off+f.miniSize > len(f.miniStream) {
+			return nil, ErrFormat
+		}
+		visited[sect] = true
+		data = append(data, f.miniStream[off:off+f.miniSize]...)
+		sect = f.miniFat[sect]
+	}
+	if uint64(len(data)) < size {
+		return nil, ErrFormat
+	}
+	return data[:size], nil
+}
+
+// HasStream 根存储下是否有该名称（不区分大小写）的流
+func (f *File) HasStream(name string) bool {
+	id, ok := f.root[strings.ToLower(name)]
+	return ok && f.entries[id].typ == typeStream
+}
+
+// Stream 按名称（不区分大小写）读取根存储下的流
+func (f *File) Stream(name string) ([]byte, error) {
+	id, ok := f.root[strings.ToLower(name)]
+	if !ok || f.entries[id].typ != typeStream {
+		return nil, errorx.Errorf(errorx.ErrParse, "stream %s not found", name)
+	}
+	e := f.entries[id]
+	if e.size < f.miniCutoff {
+		if err := f.loadMini(); err != nil {
+			return nil, err
+		}
+		return f.readMiniChain(e.start, e.size)
+	}
+	return f.readChain(e.start, e.size)
+}
+
+func parseEntry(b []byte, v3 bool) entry {
+	nameLen := int(binary.LittleEndian.Uint16(b[64:]))
+	if nameLen > 64 {
+		nameLen = 64
+	}
+	u := make([]uint16, nameLen/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(b[i*2:])
+	}
+	e := entry{
+		name:  strings.TrimRight(string(utf16.Decode(u)), "\x00"),
+		typ:   b[66],
+		left:  binary.LittleEndian.Uint32(b[68:]),
+		right: binary.LittleEndian.Uint32(b[72:]),
+		child: binary.LittleEndian.Uint32(b[76:]),
+		start: binary.LittleEndian.Uint32(b[116:]),
+		size:  binary.LittleEndian.Uint64(b[120:]),
+	}
+	// 版本 3 的文件只使用低 32 位
+	if v3 {
+		e.size &= 0xFFFFFFFF
+	}
+	return e
+}
+
+func bytesToUint32s(b []byte) []uint32 {
+	res := make([]uint32, len(b)/4)
+	for i := range res {
+		res[i] = binary.LittleEndian.Uint32(b[i*4:])
+	}
+	return res
+}
@@ -0,0 +1,101 @@
+package ole2
+
+import (
+	"bytes"
+	"encoding/binary"
+	"runtime"
+	"testing"
+)
+
+// cyclicOle 构造 16 个扇区的复合文档：扇区 0 为 FAT，1 为目录，根存储下有一个小流 S，2 为指向自身的 miniFAT 链；
+// fatCopies 大于 1 时头部重复列出 FAT 扇区，并以指向自身的扇区 3 作为 DIFAT
+func cyclicOle(fatCopies int) []byte {
+	const sectors = 16
+	b := make([]byte, 512*(sectors+1))
+	copy(b, "\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1")
+	le := binary.LittleEndian
+	le.PutUint16(b[0x18:], 0x3E)
+	le.PutUint16(b[0x1A:], 3)
+	le.PutUint16(b[0x1C:], 0xFFFE)
+	le.PutUint16(b[0x1E:], 9)
+	le.PutUint16(b[0x20:], 6)
+	le.PutUint32(b[0x2C:], 1)
+	le.PutUint32(b[0x30:], 1)
+	le.PutUint32(b[0x38:], 4096)
+	le.PutUint32(b[0x3C:], 2)
+	le.PutUint32(b[0x40:], 1)
+	le.PutUint32(b[0x44:], 0xFFFFFFFE)
+	if fatCopies > 1 {
+		le.PutUint32(b[0x2C:], 0xFFFF)
+		le.PutUint32(b[0x44:], 3)
+		le.PutUint32(b[0x48:], 1)
+	}
+	for i := 0; i < 109; i++ {
+		v := uint32(0xFFFFFFFF)
+		if i < fatCopies {
+			v = 0
+		}
+		le.PutUint32(b[0x4C+i*4:], v)
+	}
+
+	sector := func(i int) []byte { return b[512*(i+1) : 512*(i+2)] }
+	fat := sector(0)
+	for i := 0; i < 128; i++ {
+		le.PutUint32(fat[i*4:], 0xFFFFFFFF)
+	}
+	le.PutUint32(fat[0:], 0xFFFFFFFD)
+	le.PutUint32(fat[4:], 0xFFFFFFFE)
+	le.PutUint32(fat[8:], 2)
+	le.PutUint32(fat[12:], 0xFFFFFFFC)
+
+	dir := sector(1)
+	entry := func(i int, name string, typ byte, child, size uint32) {
+		e := dir[i*128 : (i+1)*128]
+		for j, c := range name + "\x00" {
+			le.PutUint16(e[j*2:], uint16(c))
+		}
+		le.PutUint16(e[64:], uint16(len(name)+1)*2)
+		e[66] = typ
+		le.PutUint32(e[68:], 0xFFFFFFFF)
+		le.PutUint32(e[72:], 0xFFFFFFFF)
+		le.PutUint32(e[76:], child)
+		le.PutUint32(e[116:], 0)
+		le.PutUint32(e[120:], size)
+	}
+	entry(0, "Root Entry", 5, 1, 64)
+	entry(1, "S", 2, 0xFFFFFFFF, 10)
+	le.PutUint32(dir[116:], 4)
+	le.PutUint32(fat[16:], 0xFFFFFFFE)
+
+	difat := sector(3)
+	le.PutUint32(difat[127*4:], 3)
+	return b
+}
+
+func TestOpenCyclicChain(t *testing.T) {
+	tests := []struct {
+		name      string
+		fatCopies int
+	}{
+		{"mini fat loop", 1},
+		{"repeated fat sector", 109},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := cyclicOle(tt.fatCopies)
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			f, err := Open(bytes.NewReader(data), int64(len(data)))
+			if err == nil {
+				_, err = f.Stream("S")
+			}
+			runtime.ReadMemStats(&after)
+			if err == nil {
+				t.Fatal("want error")
+			}
+			if n := after.TotalAlloc - before.TotalAlloc; n > 64<<10 {
+				t.Errorf("allocated %d bytes for a %d byte file", n, len(data))
+			}
+		})
+	}
+}
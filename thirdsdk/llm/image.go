@@ -2,11 +2,19 @@ package llm
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
 	"github.com/sashabaranov/go-openai"
 )
 
+// ImageDescribe 调用多模态模型描述图片内容，imageUrl 可以是 data url
 func ImageDescribe(openaiApiKey string, openaiUrl string, imageUrl string) (string, error) {
-	ctx := context.Background()
+	return ImageDescribeCtx(context.Background(), openaiApiKey, openaiUrl, imageUrl)
+}
+
+// ImageDescribeCtx 同 ImageDescribe，ctx 取消时中止请求
+func ImageDescribeCtx(ctx context.Context, openaiApiKey string, openaiUrl string, imageUrl string) (string, error) {
 	config := openai.DefaultConfig(openaiApiKey)
 	config.BaseURL = openaiUrl
 	client := openai.NewClientWithConfig(config)
@@ -37,9 +45,26 @@ func ImageDescribe(openaiApiKey string, openaiUrl string, imageUrl string) (stri
 			Messages: dialogue,
 		},
 	)
-	if err != nil || len(resp.Choices) == 0 {
-		return "", err
+	if err != nil {
+		return "", providerError(err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", nil
 	}
 
 	return resp.Choices[0].Message.Content, nil
 }
+
+// providerError 转为 errorx.ProviderError，保留接口返回的状态码和错误码
+func providerError(err error) error {
+	pe := &errorx.ProviderError{Provider: "llm", Err: err}
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		pe.StatusCode = apiErr.HTTPStatusCode
+		if apiErr.Code != nil {
+			pe.Code = fmt.Sprint(apiErr.Code)
+		}
+		pe.Message = apiErr.Message
+	}
+	return pe
+}
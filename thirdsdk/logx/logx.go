@@ -0,0 +1,127 @@
+package logx
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// Logger 日志接口，方法签名与 *slog.Logger 一致，可直接传入 slog.Default()；args 为交替的键和值
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// Level 日志级别
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	}
+	return "ERROR"
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...any) {}
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+
+// Nop 丢弃所有日志
+var Nop Logger = nopLogger{}
+
+type stdLogger struct {
+	l   *log.Logger
+	min Level
+}
+
+// NewStd 基于标准库 log 输出 level msg key=value 格式的日志，低于 min 的日志会被丢弃；
+// 键名像密钥（token、secret、password、apikey）的值会被遮蔽
+func NewStd(l *log.Logger, min Level) Logger {
+	if l == nil {
+		l = log.Default()
+	}
+	return &stdLogger{l: l, min: min}
+}
+
+func (s *stdLogger) Debug(msg string, args ...any) { s.log(LevelDebug, msg, args) }
+func (s *stdLogger) Info(msg string, args ...any)  { s.log(LevelInfo, msg, args) }
+func (s *stdLogger) Warn(msg string, args ...any)  { s.log(LevelWarn, msg, args) }
+func (s *stdLogger) Error(msg string, args ...any) { s.log(LevelError, msg, args) }
+
+func (s *stdLogger) log(level Level, msg string, args []any) {
+	if level < s.min {
+		return
+	}
+	var b strings.Builder
+	b.WriteString(level.String())
+	b.WriteString(" ")
+	b.WriteString(msg)
+	for i := 0; i < len(args); i += 2 {
+		key, val := "!BADKEY", args[i]
+		if k, ok := args[i].(string); ok && i+1 < len(args) {
+			key, val = k, args[i+1]
+		} else {
+			i--
+		}
+		v := fmt.Sprint(val)
+		if isSecretKey(key) {
+			v = Redact(v)
+		}
+		b.WriteString(" " + key + "=" + quote(v))
+	}
+	s.l.Print(b.String())
+}
+
+func quote(v string) string {
+	if v == "" || strings.ContainsAny(v, " \"=\t\n") {
+		return fmt.Sprintf("%q", v)
+	}
+	return v
+}
+
+type holder struct{ l Logger }
+
+var defaultLogger atomic.Pointer[holder]
+
+func init() {
+	defaultLogger.Store(&holder{NewStd(nil, LevelWarn)})
+}
+
+// Default 返回默认日志，未设置时输出 WARN 及以上级别到标准库 log
+func Default() Logger {
+	return defaultLogger.Load().l
+}
+
+// SetDefault 替换默认日志，影响所有未单独指定日志的客户端；传入空值时丢弃所有日志
+func SetDefault(l Logger) {
+	if l == nil {
+		l = Nop
+	}
+	defaultLogger.Store(&holder{l})
+}
+
+// Or 返回 l，l 为空时返回默认日志
+func Or(l Logger) Logger {
+	if l != nil {
+		return l
+	}
+	return Default()
+}
@@ -0,0 +1,61 @@
+package logx
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// secretWords 键名包含这些词时视为密钥
+var secretWords = []string{"token", "secret", "password", "apikey", "api_key", "client_id"}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, w := range secretWords {
+		if strings.Contains(key, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact 遮蔽密钥，只保留前 4 位便于排查
+func Redact(secret string) string {
+	if len(secret) <= 8 {
+		return "****"
+	}
+	return secret[:4] + "****"
+}
+
+// RedactUrl 遮蔽地址查询参数中的密钥，如 access_token、client_secret
+func RedactUrl(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.RawQuery == "" {
+		return rawUrl
+	}
+	query := u.Query()
+	changed := false
+	for key, values := range query {
+		if !isSecretKey(key) {
+			continue
+		}
+		for i, v := range values {
+			values[i] = Redact(v)
+		}
+		changed = true
+	}
+	if !changed {
+		return rawUrl
+	}
+	u.RawQuery = strings.ReplaceAll(query.Encode(), "%2A", "*")
+	return u.String()
+}
+
+// RedactError 遮蔽 err 中请求地址携带的密钥，http 客户端的错误会带上完整地址
+func RedactError(err error) error {
+	var ue *url.Error
+	if errors.As(err, &ue) {
+		ue.URL = RedactUrl(ue.URL)
+	}
+	return err
+}
@@ -0,0 +1,74 @@
+package ocr
+
+import (
+	"context"
+	"os"
+
+	"github.com/comqositi/toolkits/thirdsdk/baidu"
+	"github.com/comqositi/toolkits/thirdsdk/filetype"
+)
+
+type baiduProvider struct {
+	client *baidu.BaiduOcr
+}
+
+// NewBaidu 百度通用文字识别，支持 jpg/png/bmp 图片和 pdf
+func NewBaidu(client *baidu.BaiduOcr) OCRProvider {
+	return &baiduProvider{client: client}
+}
+
+func (p *baiduProvider) Name() string {
+	return "baidu"
+}
+
+func (p *baiduProvider) Recognize(ctx context.Context, in Input) (*Result, error) {
+	t, err := in.FileType()
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var (
+		word, suffix string
+		size         int
+	)
+	switch {
+	case t == filetype.Pdf:
+		// PdfToWord 会删除传入的文件，统一使用临时副本
+		filePath, err := tempFile(ctx, p.client.Fetcher(), in, t.Ext)
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(filePath)
+		if in.Page > 0 {
+			word, suffix, size, err = p.client.PdfPageToWordCtx(ctx, filePath, in.Page)
+		} else {
+			word, suffix, size, err = p.client.PdfToWordCtx(ctx, filePath)
+		}
+		if err != nil {
+			return nil, err
+		}
+	case t.IsImage():
+		if len(in.Data) == 0 && in.FilePath == "" {
+			word, suffix, size, err = p.client.ImageUrlToWordCtx(ctx, in.Url)
+		} else {
+			filePath := in.FilePath
+			if len(in.Data) > 0 {
+				if filePath, err = tempFile(ctx, p.client.Fetcher(), in, t.Ext); err != nil {
+					return nil, err
+				}
+				defer os.Remove(filePath)
+			}
+			word, suffix, size, err = p.client.ImageToWordCtx(ctx, filePath)
+		}
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrUnsupported
+	}
+
+	return &Result{Text: word, Provider: p.Name(), Suffix: suffix, Size: size, Page: in.Page}, nil
+}
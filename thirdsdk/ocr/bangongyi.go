@@ -0,0 +1,55 @@
+package ocr
+
+import (
+	"context"
+
+	"github.com/comqositi/toolkits/thirdsdk/bangongyi"
+	"github.com/comqositi/toolkits/thirdsdk/filetype"
+)
+
+// bangongyiMaxPages 办公易单次最多识别的 pdf 页数
+const bangongyiMaxPages = 200
+
+type bangongyiProvider struct {
+	endpoint string
+}
+
+// NewBangongyi 办公易识别服务，endpoint 为接口地址；只能识别远程地址，不支持按页识别
+func NewBangongyi(endpoint string) OCRProvider {
+	return &bangongyiProvider{endpoint: endpoint}
+}
+
+func (p *bangongyiProvider) Name() string {
+	return "bangongyi"
+}
+
+func (p *bangongyiProvider) Recognize(ctx context.Context, in Input) (*Result, error) {
+	if in.Url == "" || in.Page > 0 {
+		return nil, ErrUnsupported
+	}
+	t, err := in.FileType()
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var (
+		word, suffix string
+		size         int
+	)
+	switch {
+	case t == filetype.Pdf:
+		word, suffix, size, err = bangongyi.PdfToContentCtx(ctx, p.endpoint, in.Url, bangongyiMaxPages)
+	case t.IsImage():
+		word, suffix, size, err = bangongyi.ImageToContentCtx(ctx, p.endpoint, in.Url)
+	default:
+		return nil, ErrUnsupported
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{Text: word, Provider: p.Name(), Suffix: suffix, Size: size}, nil
+}
@@ -0,0 +1,48 @@
+package ocr
+
+import (
+	"context"
+
+	"github.com/comqositi/toolkits/thirdsdk/llm"
+)
+
+type llmProvider struct {
+	apiKey  string
+	baseUrl string
+}
+
+// NewLlm 多模态大模型识别图片内容，本地图片以 data url 形式上传；不支持 pdf
+func NewLlm(apiKey string, baseUrl string) OCRProvider {
+	return &llmProvider{apiKey: apiKey, baseUrl: baseUrl}
+}
+
+func (p *llmProvider) Name() string {
+	return "llm"
+}
+
+func (p *llmProvider) Recognize(ctx context.Context, in Input) (*Result, error) {
+	t, err := in.FileType()
+	if err != nil {
+		return nil, err
+	}
+	if !t.IsImage() {
+		return nil, ErrUnsupported
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	imageUrl := in.Url
+	if len(in.Data) > 0 || in.FilePath != "" {
+		if imageUrl, err = dataUrl(in, t.Mime); err != nil {
+			return nil, err
+		}
+	}
+
+	word, err := llm.ImageDescribeCtx(ctx, p.apiKey, p.baseUrl, imageUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{Text: word, Provider: p.Name(), Suffix: t.Ext, Size: in.Size()}, nil
+}
@@ -0,0 +1,113 @@
+package ocr
+
+import (
+	"context"
+
+	"github.com/comqositi/toolkits/thirdsdk/baidu"
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
+	"github.com/comqositi/toolkits/thirdsdk/filetype"
+)
+
+// ErrUnsupported 识别服务不支持该输入，调用方可换用其他服务
+var ErrUnsupported = errorx.New(errorx.ErrUnsupportedFormat, "input not supported by provider")
+
+// Input 识别输入，Data、FilePath、Url 三选一
+type Input struct {
+	// Data 文件内容
+	Data []byte
+	// FilePath 本地文件路径
+	FilePath string
+	// Url 远程文件地址
+	Url string
+	// Mime 文件类型，为空时按内容或地址识别
+	Mime string
+	// Page pdf 页码，从 1 开始，为 0 表示识别全部页
+	Page int
+}
+
+// Result 识别结果
+type Result struct {
+	// Text 识别出的文字
+	Text string
+	// Provider 产生结果的识别服务名称
+	Provider string
+	// Suffix 文件后缀
+	Suffix string
+	// Size 文件大小
+	Size int
+	// Page 识别的 pdf 页码，为 0 表示全部页或图片
+	Page int
+	// Attempts 经路由识别时的尝试记录
+	Attempts []Attempt
+}
+
+// OCRProvider 识别服务，将图片或 pdf 页转为文字
+type OCRProvider interface {
+	// Name 服务名称，用于配置与审计
+	Name() string
+	Recognize(ctx context.Context, in Input) (*Result, error)
+}
+
+// FileType 返回输入的文件格式
+func (in Input) FileType() (filetype.Type, error) {
+	if t := filetype.ByMime(in.Mime); !t.IsUnknown() {
+		return t, nil
+	}
+	switch {
+	case len(in.Data) > 0:
+		return filetype.DetectBytes(in.Data), nil
+	case in.FilePath != "":
+		return filetype.DetectFile(in.FilePath)
+	case in.Url != "":
+		ext, err := filetype.ExtFromPath(in.Url)
+		if err != nil {
+			return filetype.Unknown, err
+		}
+		return filetype.ByExt(ext), nil
+	}
+	return filetype.Unknown, errorx.ErrEmptyInput
+}
+
+// Size 返回输入的大小，远程地址未下载时返回 0
+func (in Input) Size() int {
+	if len(in.Data) > 0 {
+		return len(in.Data)
+	}
+	if in.FilePath != "" {
+		if size, err := countSize(in.FilePath); err == nil {
+			return size
+		}
+	}
+	return 0
+}
+
+// Config 识别服务配置，Name 为 baidu、bangongyi 或 llm
+type Config struct {
+	Name      string `json:"name"`
+	ApiKey    string `json:"api_key"`
+	ApiSecret string `json:"api_secret"`
+	// Endpoint 办公易接口地址或大模型 BaseURL
+	Endpoint string `json:"endpoint"`
+}
+
+// New 按配置创建识别服务，cache 仅百度使用
+func New(cfg Config, cache baidu.Cache) (OCRProvider, error) {
+	return NewCtx(context.Background(), cfg, cache)
+}
+
+// NewCtx 同 New，ctx 取消时中止请求
+func NewCtx(ctx context.Context, cfg Config, cache baidu.Cache) (OCRProvider, error) {
+	switch cfg.Name {
+	case "baidu":
+		client, err := baidu.NewBaiduOcrCtx(ctx, cfg.ApiKey, cfg.ApiSecret, cache)
+		if err != nil {
+			return nil, err
+		}
+		return NewBaidu(client), nil
+	case "bangongyi":
+		return NewBangongyi(cfg.Endpoint), nil
+	case "llm":
+		return NewLlm(cfg.ApiKey, cfg.Endpoint), nil
+	}
+	return nil, errorx.Errorf(errorx.ErrNotConfigured, "unknown provider %q", cfg.Name)
+}
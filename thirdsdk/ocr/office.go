@@ -0,0 +1,24 @@
+package ocr
+
+import (
+	"context"
+
+	"github.com/comqositi/toolkits/thirdsdk/filetype"
+	"github.com/comqositi/toolkits/thirdsdk/office"
+)
+
+// PageRecognizer 将识别服务转为 office 扫描件识别使用的 office.PageRecognizer；
+// 能提取到页面图片时识别图片，否则按页码识别原始 pdf
+func PageRecognizer(p OCRProvider) office.PageRecognizer {
+	return office.PageRecognizerFunc(func(ctx context.Context, page office.PageImage) (string, error) {
+		in := Input{FilePath: page.PdfPath, Mime: filetype.Pdf.Mime, Page: page.Page}
+		if len(page.Data) > 0 {
+			in = Input{Data: page.Data, Mime: page.Mime}
+		}
+		res, err := p.Recognize(ctx, in)
+		if err != nil {
+			return "", err
+		}
+		return res.Text, nil
+	})
+}
@@ -0,0 +1,180 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
+	"github.com/comqositi/toolkits/thirdsdk/filetype"
+)
+
+// ErrEmpty 识别结果为空，路由时会换用下一个服务
+var ErrEmpty = errorx.ErrEmptyResult
+
+// Route 路由规则，匹配的输入优先交给 Providers 按顺序尝试
+type Route struct {
+	// Types 匹配的文件格式，为空表示不限制
+	Types []filetype.Type
+	// MinSize、MaxSize 匹配的文件大小（字节），为 0 表示不限制；远程地址大小未知时不参与判断
+	MinSize   int
+	MaxSize   int
+	Providers []OCRProvider
+}
+
+// Attempt 一次识别尝试的记录
+type Attempt struct {
+	Provider string        `json:"provider"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// RouterError 所有服务均识别失败
+type RouterError struct {
+	Attempts []Attempt
+	// Err 最后一个服务返回的错误
+	Err error
+}
+
+func (e *RouterError) Error() string {
+	msgs := make([]string, 0, len(e.Attempts))
+	for _, a := range e.Attempts {
+		msgs = append(msgs, a.Provider+": "+a.Error)
+	}
+	return "recognition failed: " + strings.Join(msgs, "; ")
+}
+
+func (e *RouterError) Unwrap() error {
+	return e.Err
+}
+
+// Router 按规则选择识别服务，出错、额度用尽或结果为空时依次换用后备服务
+type Router struct {
+	routes   []Route
+	fallback []OCRProvider
+}
+
+// NewRouter 创建路由，fallback 为没有规则匹配或规则内服务全部失败时使用的服务
+func NewRouter(fallback ...OCRProvider) *Router {
+	return &Router{fallback: fallback}
+}
+
+// AddRoute 添加路由规则，按添加顺序匹配第一条
+func (r *Router) AddRoute(route Route) *Router {
+	r.routes = append(r.routes, route)
+	return r
+}
+
+func (r *Router) Name() string {
+	return "router"
+}
+
+// Recognize 返回的 Result.Attempts 记录了每次尝试，Result.Provider 为最终产生结果的服务
+func (r *Router) Recognize(ctx context.Context, in Input) (*Result, error) {
+	providers, err := r.providers(in)
+	if err != nil {
+		return nil, err
+	}
+	if len(providers) == 0 {
+		return nil, errorx.ErrNotConfigured
+	}
+
+	var (
+		attempts []Attempt
+		lastErr  error
+	)
+	for _, p := range providers {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		start := time.Now()
+		res, err := p.Recognize(ctx, in)
+		attempt := Attempt{Provider: p.Name(), Duration: time.Since(start)}
+		// 没有结果与结果为空同样换用下一个服务
+		if err == nil && (res == nil || strings.TrimSpace(res.Text) == "") {
+			err = ErrEmpty
+		}
+		if err == nil {
+			// 嵌套的路由已经记录了内部尝试
+			if len(res.Attempts) > 0 {
+				res.Attempts = append(attempts, res.Attempts...)
+			} else {
+				res.Attempts = append(attempts, attempt)
+			}
+			return res, nil
+		}
+
+		var routerErr *RouterError
+		if errors.As(err, &routerErr) {
+			attempts = append(attempts, routerErr.Attempts...)
+		} else {
+			attempt.Error = err.Error()
+			attempts = append(attempts, attempt)
+		}
+		lastErr = err
+	}
+	return nil, &RouterError{Attempts: attempts, Err: lastErr}
+}
+
+// providers 返回匹配规则的服务，其后追加未重复的后备服务
+func (r *Router) providers(in Input) ([]OCRProvider, error) {
+	t, err := in.FileType()
+	if err != nil {
+		return nil, err
+	}
+	size := in.Size()
+
+	var res []OCRProvider
+	for _, route := range r.routes {
+		if route.match(t, size) {
+			res = append(res, route.Providers...)
+			break
+		}
+	}
+	// 按实例去重：同名但配置不同的 provider（如不同账号）都会保留
+	for _, p := range r.fallback {
+		dup := false
+		for _, q := range res {
+			if sameProvider(q, p) {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			res = append(res, p)
+		}
+	}
+	return res, nil
+}
+
+// sameProvider 比较是否为同一个实例，不可比较的类型（如含 map 的值类型）视为不同
+func sameProvider(a, b OCRProvider) bool {
+	ta := reflect.TypeOf(a)
+	return ta != nil && ta == reflect.TypeOf(b) && ta.Comparable() && a == b
+}
+
+func (route Route) match(t filetype.Type, size int) bool {
+	if len(route.Types) > 0 {
+		ok := false
+		for _, rt := range route.Types {
+			if rt == t {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if size > 0 {
+		if route.MinSize > 0 && size < route.MinSize {
+			return false
+		}
+		if route.MaxSize > 0 && size > route.MaxSize {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/comqositi/toolkits/thirdsdk/filetype"
+)
+
+type fakeProvider struct {
+	name  string
+	res   *Result
+	err   error
+	calls *[]string
+}
+
+func (p *fakeProvider) Name() string {
+	return p.name
+}
+
+func (p *fakeProvider) Recognize(ctx context.Context, in Input) (*Result, error) {
+	*p.calls = append(*p.calls, p.name)
+	return p.res, p.err
+}
+
+func TestRouterFallback(t *testing.T) {
+	var calls []string
+	var (
+		none  = &fakeProvider{name: "none", calls: &calls}
+		empty = &fakeProvider{name: "empty", res: &Result{Text: " \n"}, calls: &calls}
+		fail  = &fakeProvider{name: "fail", err: errors.New("quota exceeded"), calls: &calls}
+		ok    = &fakeProvider{name: "ok", res: &Result{Text: "hello", Provider: "ok"}, calls: &calls}
+	)
+	r := NewRouter(fail, ok).AddRoute(Route{Types: []filetype.Type{filetype.Png}, Providers: []OCRProvider{none, empty, fail}})
+
+	res, err := r.Recognize(context.Background(), Input{Data: []byte("data"), Mime: "image/png"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Text != "hello" {
+		t.Errorf("text = %q", res.Text)
+	}
+	// 后备服务中重复的 fail 只尝试一次
+	if want := []string{"none", "empty", "fail", "ok"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+	var got [][2]string
+	for _, a := range res.Attempts {
+		got = append(got, [2]string{a.Provider, a.Error})
+	}
+	want := [][2]string{{"none", ErrEmpty.Error()}, {"empty", ErrEmpty.Error()}, {"fail", "quota exceeded"}, {"ok", ""}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("attempts = %q, want %q", got, want)
+	}
+}
+
+func TestRouterAllFailed(t *testing.T) {
+	var calls []string
+	r := NewRouter(&fakeProvider{name: "fail", err: errors.New("down"), calls: &calls}, &fakeProvider{name: "none", calls: &calls})
+
+	_, err := r.Recognize(context.Background(), Input{Data: []byte("data"), Mime: "image/png"})
+	var routerErr *RouterError
+	if !errors.As(err, &routerErr) {
+		t.Fatalf("err = %v, want RouterError", err)
+	}
+	if len(routerErr.Attempts) != 2 || !errors.Is(err, ErrEmpty) {
+		t.Errorf("attempts = %+v, err = %v", routerErr.Attempts, err)
+	}
+}
@@ -0,0 +1,94 @@
+package ocr
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"os"
+
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
+	"github.com/comqositi/toolkits/thirdsdk/fetcher"
+)
+
+func countSize(filePath string) (int, error) {
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		return 0, errorx.Wrap(errorx.ErrRead, err)
+	}
+
+	fileSize := int(fileInfo.Size())
+	return fileSize, nil
+}
+
+// tempFile 将输入复制为本地临时文件，远程地址使用 f 下载，调用方负责删除
+func tempFile(ctx context.Context, f *fetcher.Fetcher, in Input, ext string) (string, error) {
+	pattern := "ocr*"
+	if ext != "" {
+		pattern += "." + ext
+	}
+	tmp, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", errorx.Wrap(errorx.ErrTempFile, err)
+	}
+	defer tmp.Close()
+
+	err = writeInput(ctx, f, tmp, in)
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+func writeInput(ctx context.Context, f *fetcher.Fetcher, w io.Writer, in Input) error {
+	switch {
+	case len(in.Data) > 0:
+		_, err := w.Write(in.Data)
+		if err != nil {
+			return errorx.Wrap(errorx.ErrTempFile, err)
+		}
+		return nil
+	case in.FilePath != "":
+		src, err := os.Open(in.FilePath)
+		if err != nil {
+			return errorx.Wrap(errorx.ErrRead, err)
+		}
+		defer src.Close()
+		if _, err := io.Copy(w, src); err != nil {
+			return errorx.Wrap(errorx.ErrTempFile, err)
+		}
+		return nil
+	case in.Url != "":
+		file, err := f.Fetch(ctx, in.Url)
+		if err != nil {
+			return err
+		}
+		defer file.Remove()
+		src, err := os.Open(file.Path)
+		if err != nil {
+			return errorx.Wrap(errorx.ErrRead, err)
+		}
+		defer src.Close()
+		if _, err := io.Copy(w, src); err != nil {
+			return errorx.Wrap(errorx.ErrTempFile, err)
+		}
+		return nil
+	}
+	return errorx.ErrEmptyInput
+}
+
+// dataUrl 将本地内容编码为 data url
+func dataUrl(in Input, mime string) (string, error) {
+	data := in.Data
+	if len(data) == 0 {
+		if in.FilePath == "" {
+			return "", errorx.ErrEmptyInput
+		}
+		var err error
+		data, err = os.ReadFile(in.FilePath)
+		if err != nil {
+			return "", errorx.Wrap(errorx.ErrRead, err)
+		}
+	}
+	return "data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,422 @@
+package office
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/comqositi/toolkits/thirdsdk/charset"
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
+)
+
+const (
+	// 识别格式时读取的字节数
+	csvSniffSize = 64 << 10
+	// 识别格式时最多解析的行数
+	csvSniffRows = 20
+	// 单个字段和单行的最大字节数，避免缺少结束引号时整个文件被读入一个字段
+	csvMaxFieldSize  = 1 << 20
+	csvMaxRecordSize = 8 << 20
+)
+
+var (
+	errCsvQuote    = errorx.New(errorx.ErrParse, "unclosed quote at end of file")
+	errCsvTooLarge = errorx.New(errorx.ErrTooLarge, "csv field or record too large")
+)
+
+// csvDelimiters 可识别的分隔符，行内列数同样一致时靠前的优先
+var csvDelimiters = []rune{',', '\t', ';', '|'}
+
+// CsvDialect 识别出的 csv 格式
+type CsvDialect struct {
+	Delimiter rune   `json:"delimiter"` // 分隔符：逗号、制表符、分号或竖线
+	Quote     rune   `json:"quote"`     // 引号：双引号或单引号，默认双引号
+	Encoding  string `json:"encoding"`  // 原始编码，如 utf-8、gb18030
+	Header    bool   `json:"header"`    // 首行为表头
+}
+
+// CsvReader 逐行读取 csv 或 tsv，用于不便一次读入内存的大文件
+type CsvReader struct {
+	p       *csvParser
+	dialect CsvDialect
+	header  []string
+}
+
+// NewCsvReader 读取开头一段识别编码、分隔符、引号和表头，之后通过 Read 逐行读取
+func NewCsvReader(r io.Reader) (*CsvReader, error) {
+	dec, encoding, err := charset.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReaderSize(dec, csvSniffSize)
+	sample, err := br.Peek(csvSniffSize)
+	if err != nil && err != io.EOF {
+		return nil, errorx.Wrap(errorx.ErrRead, err)
+	}
+	// 没有读完时去掉最后不完整的一行
+	if err == nil {
+		if i := bytes.LastIndexByte(sample, '\n'); i > 0 {
+			sample = sample[:i+1]
+		}
+	}
+
+	dialect := sniffCsv(string(sample))
+	dialect.Encoding = encoding
+	c := &CsvReader{p: &csvParser{r: br, delimiter: dialect.Delimiter, quote: dialect.Quote}, dialect: dialect}
+	if dialect.Header {
+		if c.header, err = c.p.read(); err != nil {
+			return nil, errorx.Wrap(errorx.ErrParse, err)
+		}
+	}
+	return c, nil
+}
+
+// Dialect 识别出的格式
+func (c *CsvReader) Dialect() CsvDialect {
+	return c.dialect
+}
+
+// Header 表头，没有识别出表头时为 nil
+func (c *CsvReader) Header() []string {
+	return c.header
+}
+
+// Read 读取下一行，跳过空行，表头不会重复返回；读完时返回 io.EOF
+func (c *CsvReader) Read() ([]string, error) {
+	row, err := c.p.read()
+	if err != nil && err != io.EOF {
+		return nil, errorx.Wrap(errorx.ErrParse, err)
+	}
+	return row, err
+}
+
+// readAll 读取包括表头在内的所有行
+func (c *CsvReader) readAll() ([][]string, error) {
+	var rows [][]string
+	if c.header != nil {
+		rows = append(rows, c.header)
+	}
+	for {
+		row, err := c.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+}
+
+// CsvToContent csv 或 tsv 文件转文字，结果与 ExcelToContentTwo 相同，整个文件作为一个没有名称的工作表，首行表头保留在 Content 中
+func CsvToContent(filePath string) (word []ExcelResult, fileSuffix string, FileSize int, err error) {
+	suffix, err := detectSuffix(filePath, filePath)
+	if err != nil {
+		return nil, "", 0, errorx.Wrap(errorx.ErrUnsupportedFormat, err)
+	}
+	size, err := countSize(filePath)
+	if err != nil {
+		return nil, "", 0, errorx.Wrap(errorx.ErrRead, err)
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, "", 0, errorx.Wrap(errorx.ErrRead, err)
+	}
+	defer f.Close()
+
+	word, err = parseCsv(f)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	return word, suffix, size, nil
+}
+
+// CsvUrlToContent 同 CsvToContent，读取 url 文件
+func CsvUrlToContent(url string) (word []ExcelResult, fileSuffix string, FileSize int, err error) {
+	return CsvUrlToContentCtx(context.Background(), url)
+}
+
+// CsvUrlToContentCtx 同 CsvUrlToContent，ctx 取消时中止请求
+func CsvUrlToContentCtx(ctx context.Context, url string) (word []ExcelResult, fileSuffix string, FileSize int, err error) {
+	suffix, _ := getSuffix(url)
+	filePath, err := saveFile(ctx, url, suffix)
+	if err != nil {
+		return nil, "", 0, errorx.Wrap(errorx.ErrDownload, err)
+	}
+	defer os.Remove(filePath)
+
+	return CsvToContent(filePath)
+}
+
+// CsvFromReader 同 CsvToContent，从 r 中读取，不落盘；后缀按识别出的分隔符返回 csv 或 tsv
+func CsvFromReader(r io.ReaderAt, size int64) (word []ExcelResult, fileSuffix string, FileSize int, err error) {
+	cr, err := NewCsvReader(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return nil, "", 0, err
+	}
+	rows, err := cr.readAll()
+	if err != nil {
+		return nil, "", 0, err
+	}
+	suffix := "csv"
+	if cr.dialect.Delimiter == '\t' {
+		suffix = "tsv"
+	}
+	return []ExcelResult{{Content: rows}}, suffix, int(size), nil
+}
+
+// CsvFromBytes 同 CsvFromReader，读取内存中的文件
+func CsvFromBytes(data []byte) (word []ExcelResult, fileSuffix string, FileSize int, err error) {
+	return CsvFromReader(bytes.NewReader(data), int64(len(data)))
+}
+
+func parseCsv(r io.Reader) ([]ExcelResult, error) {
+	cr, err := NewCsvReader(r)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := cr.readAll()
+	if err != nil {
+		return nil, err
+	}
+	return []ExcelResult{{Content: rows}}, nil
+}
+
+// sniffCsv 按样本识别引号、分隔符和表头
+func sniffCsv(sample string) CsvDialect {
+	dialect := CsvDialect{Delimiter: ',', Quote: sniffQuote(sample)}
+	var rows [][]string
+	best := 0
+	for _, d := range csvDelimiters {
+		candidate := csvSampleRows(sample, d, dialect.Quote)
+		if score := csvConsistency(candidate); score > best {
+			dialect.Delimiter, rows, best = d, candidate, score
+		}
+	}
+	if rows == nil {
+		rows = csvSampleRows(sample, dialect.Delimiter, dialect.Quote)
+	}
+	dialect.Header = sniffHeader(rows)
+	return dialect
+}
+
+// sniffQuote 统计出现在字段开头的双引号和单引号，单引号更多时使用单引号
+func sniffQuote(sample string) rune {
+	var double, single int
+	delimiters := string(csvDelimiters)
+	prev := '\n'
+	for _, r := range sample {
+		if prev == '\n' || prev == '\r' || strings.ContainsRune(delimiters, prev) {
+			switch r {
+			case '"':
+				double++
+			case '\'':
+				single++
+			}
+		}
+		prev = r
+	}
+	if single > double {
+		return '\''
+	}
+	return '"'
+}
+
+func csvSampleRows(sample string, delimiter, quote rune) [][]string {
+	p := &csvParser{r: strings.NewReader(sample), delimiter: delimiter, quote: quote}
+	var rows [][]string
+	for len(rows) < csvSniffRows {
+		row, err := p.read()
+		if err != nil {
+			break
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// csvConsistency 列数与首行相同且多于一列的行数，只有一行时按该行是否多于一列计分
+func csvConsistency(rows [][]string) int {
+	if len(rows) == 0 || len(rows[0]) < 2 {
+		return 0
+	}
+	score := 0
+	for _, row := range rows {
+		if len(row) == len(rows[0]) {
+			score++
+		}
+	}
+	return score
+}
+
+// sniffHeader 按列比较首行和其余行：其余行为数字而首行不是，或其余行长度一致而首行不同，计为表头；
+// 都无法判断时，首行非空、不重复、不是数字且比下面的内容短，也视为表头
+func sniffHeader(rows [][]string) bool {
+	if len(rows) < 2 {
+		return false
+	}
+	header := rows[0]
+	votes, decided := 0, false
+	for j, name := range header {
+		numeric, sameLen, length, n := true, true, -1, 0
+		for _, row := range rows[1:] {
+			if j >= len(row) || strings.TrimSpace(row[j]) == "" {
+				continue
+			}
+			v := strings.TrimSpace(row[j])
+			n++
+			if !isCsvNumber(v) {
+				numeric = false
+			}
+			if l := utf8.RuneCountInString(v); length < 0 {
+				length = l
+			} else if l != length {
+				sameLen = false
+			}
+		}
+		if n == 0 {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		switch {
+		case numeric:
+			decided = true
+			if isCsvNumber(name) {
+				votes--
+			} else {
+				votes++
+			}
+		case sameLen && n > 1:
+			// 短表头与内容等长很常见，只在长度不同时计票
+			decided = true
+			if utf8.RuneCountInString(name) != length {
+				votes++
+			}
+		}
+	}
+	if decided {
+		return votes > 0
+	}
+	return looksLikeHeader(rows)
+}
+
+func looksLikeHeader(rows [][]string) bool {
+	seen := make(map[string]bool)
+	for j, cell := range rows[0] {
+		name := strings.TrimSpace(cell)
+		if name == "" || seen[name] || isCsvNumber(name) {
+			return false
+		}
+		seen[name] = true
+		var total, n int
+		for _, row := range rows[1:] {
+			if j < len(row) {
+				total += utf8.RuneCountInString(strings.TrimSpace(row[j]))
+				n++
+			}
+		}
+		if n > 0 && utf8.RuneCountInString(name)*n >= total {
+			return false
+		}
+	}
+	return true
+}
+
+func isCsvNumber(s string) bool {
+	s = strings.TrimSuffix(strings.ReplaceAll(s, ",", ""), "%")
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// csvParser 按分隔符和引号逐行解析，引号内可以包含分隔符和换行，两个连续引号表示一个引号；
+// 不在字段开头的引号按普通字符处理；读到文件末尾时引号仍未闭合视为解析错误
+type csvParser struct {
+	r         io.RuneScanner
+	delimiter rune
+	quote     rune
+}
+
+// read 读取下一行，跳过空行
+func (p *csvParser) read() ([]string, error) {
+	for {
+		row, err := p.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if len(row) > 1 || row[0] != "" {
+			return row, nil
+		}
+	}
+}
+
+func (p *csvParser) readLine() ([]string, error) {
+	var (
+		row    []string
+		field  strings.Builder
+		quoted bool // 当前字段以引号开头
+		inside bool // 位于引号内
+		empty  = true
+		size   int
+	)
+	for {
+		r, n, err := p.r.ReadRune()
+		if err == io.EOF {
+			if empty {
+				return nil, io.EOF
+			}
+			if inside {
+				return nil, errCsvQuote
+			}
+			return append(row, field.String()), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		empty = false
+		if size += n; size > csvMaxRecordSize || field.Len() > csvMaxFieldSize {
+			return nil, errCsvTooLarge
+		}
+		if inside {
+			if r != p.quote {
+				field.WriteRune(r)
+				continue
+			}
+			next, _, err := p.r.ReadRune()
+			if err == nil && next == p.quote {
+				field.WriteRune(p.quote)
+				continue
+			}
+			if err == nil {
+				_ = p.r.UnreadRune()
+			}
+			inside = false
+			continue
+		}
+		switch r {
+		case p.delimiter:
+			row = append(row, field.String())
+			field.Reset()
+			quoted = false
+		case '\r', '\n':
+			if r == '\r' {
+				if next, _, err := p.r.ReadRune(); err == nil && next != '\n' {
+					_ = p.r.UnreadRune()
+				}
+			}
+			return append(row, field.String()), nil
+		case p.quote:
+			if field.Len() == 0 && !quoted {
+				quoted, inside = true, true
+				continue
+			}
+			field.WriteRune(r)
+		default:
+			field.WriteRune(r)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package office
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
+	"github.com/comqositi/toolkits/thirdsdk/filetype"
+	"github.com/tealeg/xlsx"
+)
+
+// CellType 单元格值的类型
+type CellType string
+
+const (
+	CellEmpty  CellType = ""
+	CellString CellType = "string"
+	CellNumber CellType = "number"
+	CellBool   CellType = "bool"
+	CellDate   CellType = "date"
+	CellError  CellType = "error"
+)
+
+// Cell 带类型的单元格
+type Cell struct {
+	Type    CellType   `json:"type,omitempty"`
+	Text    string     `json:"text"`              // 按单元格格式显示的文本，与 ExcelToContentTwo 的结果一致
+	Number  float64    `json:"number,omitempty"`  // 数值，Type 为 number 时有效
+	Bool    bool       `json:"bool,omitempty"`    // 布尔值，Type 为 bool 时有效
+	Time    *time.Time `json:"time,omitempty"`    // 日期时间，Type 为 date 时有效
+	Formula string     `json:"formula,omitempty"` // 公式，值为文件中缓存的计算结果；xls 只还原常见的运算符、引用和函数，无法还原时为空
+	Merged  *CellRange `json:"merged,omitempty"`  // 所在的合并区域，区域内有数据的行都取左上角单元格的值
+	Hidden  bool       `json:"hidden,omitempty"`  // 所在的行或列被隐藏
+	Row     int        `json:"row"`               // 原始行号，从 0 开始，SkipHidden 时与 Cells 中的下标不同
+	Col     int        `json:"col"`               // 原始列号，从 0 开始
+}
+
+// CellRange 单元格区域，行列号从 0 开始，包含首尾
+type CellRange struct {
+	FirstRow int `json:"first_row"`
+	FirstCol int `json:"first_col"`
+	LastRow  int `json:"last_row"`
+	LastCol  int `json:"last_col"`
+}
+
+// ExcelSheet 带类型的工作表
+type ExcelSheet struct {
+	Name   string      `json:"name"`
+	Hidden bool        `json:"hidden,omitempty"` // 工作表被隐藏
+	Rows   int         `json:"rows"`             // 行数
+	Cols   int         `json:"cols"`             // 列数
+	Merged []CellRange `json:"merged,omitempty"` // 合并区域，行列号为原始位置
+	Cells  [][]Cell    `json:"cells"`
+}
+
+// ExcelOptions 读取带类型工作表的选项
+type ExcelOptions struct {
+	// SkipHidden 跳过隐藏的工作表、行和列，默认保留并标记 Hidden；
+	// 跳过后 Cells 中的下标不再等于原始行列号，原始位置见 Cell.Row、Cell.Col
+	SkipHidden bool
+}
+
+// ExcelToSheets 读取 xlsx 或 xls 中带类型的单元格
+func ExcelToSheets(filePath string, opts ExcelOptions) (sheets []ExcelSheet, fileSuffix string, FileSize int, err error) {
+	t, err := detectType(filePath, filePath)
+	if err != nil {
+		return nil, "", 0, errorx.Wrap(errorx.ErrUnsupportedFormat, err)
+	}
+	size, err := countSize(filePath)
+	if err != nil {
+		return nil, "", 0, errorx.Wrap(errorx.ErrRead, err)
+	}
+	err = readLocalFile(filePath, func(r io.ReaderAt, size int64) error {
+		sheets, err = parseExcelSheets(r, size, t, opts)
+		return err
+	})
+	if err != nil {
+		return nil, "", 0, err
+	}
+	return sheets, t.Ext, size, nil
+}
+
+// ExcelUrlToSheets 同 ExcelToSheets，读取 url 文件
+func ExcelUrlToSheets(url string, opts ExcelOptions) (sheets []ExcelSheet, fileSuffix string, FileSize int, err error) {
+	return ExcelUrlToSheetsCtx(context.Background(), url, opts)
+}
+
+// ExcelUrlToSheetsCtx 同 ExcelUrlToSheets，ctx 取消时中止请求
+func ExcelUrlToSheetsCtx(ctx context.Context, url string, opts ExcelOptions) (sheets []ExcelSheet, fileSuffix string, FileSize int, err error) {
+	suffix, _ := getSuffix(url)
+	filePath, err := saveFile(ctx, url, suffix)
+	if err != nil {
+		return nil, "", 0, errorx.Wrap(errorx.ErrDownload, err)
+	}
+	defer os.Remove(filePath)
+
+	return ExcelToSheets(filePath, opts)
+}
+
+// ExcelSheetsFromReader 同 ExcelToSheets，从 r 中读取，不落盘
+func ExcelSheetsFromReader(r io.ReaderAt, size int64, opts ExcelOptions) (sheets []ExcelSheet, fileSuffix string, FileSize int, err error) {
+	t, err := readerType(r, size)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	sheets, err = parseExcelSheets(r, size, t, opts)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	return sheets, t.Ext, int(size), nil
+}
+
+// ExcelSheetsFromBytes 同 ExcelSheetsFromReader，读取内存中的文件
+func ExcelSheetsFromBytes(data []byte, opts ExcelOptions) (sheets []ExcelSheet, fileSuffix string, FileSize int, err error) {
+	return ExcelSheetsFromReader(bytes.NewReader(data), int64(len(data)), opts)
+}
+
+func parseExcelSheets(r io.ReaderAt, size int64, t filetype.Type, opts ExcelOptions) ([]ExcelSheet, error) {
+	var grids []*sheetGrid
+	if t == filetype.Xls {
+		sheets, err := readXlsSheets(r, size)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range sheets {
+			grids = append(grids, s.grid())
+		}
+	} else {
+		xlFile, err := xlsx.OpenReaderAt(r, size)
+		if err != nil {
+			return nil, errorx.Wrap(errorx.ErrRead, err)
+		}
+		for _, sheet := range xlFile.Sheets {
+			grids = append(grids, xlsxGrid(sheet, xlFile.Date1904))
+		}
+	}
+
+	var res []ExcelSheet
+	for _, g := range grids {
+		if opts.SkipHidden && g.hidden {
+			continue
+		}
+		res = append(res, g.sheet(opts))
+	}
+	return res, nil
+}
+
+// sheetGrid 各格式读出的原始工作表，由 sheet 统一处理合并区域和隐藏行列
+type sheetGrid struct {
+	name       string
+	hidden     bool
+	rows, cols int
+	cells      [][]Cell
+	merged     []CellRange
+	hiddenRows map[int]bool
+	hiddenCols map[int]bool
+}
+
+func (g *sheetGrid) sheet(opts ExcelOptions) ExcelSheet {
+	cells := g.cells
+	// 合并区域来自文件，可能远大于实际数据（如整张表），只填充已有数据的行，列不超过已用列数
+	for i := range g.merged {
+		m := &g.merged[i]
+		if m.FirstRow >= len(cells) || m.FirstCol >= len(cells[m.FirstRow]) {
+			continue
+		}
+		origin := cells[m.FirstRow][m.FirstCol]
+		origin.Merged = m
+		lastCol := m.LastCol
+		if lastCol >= g.cols {
+			lastCol = g.cols - 1
+		}
+		for r := m.FirstRow; r <= m.LastRow && r < len(cells); r++ {
+			if len(cells[r]) == 0 {
+				continue
+			}
+			for c := m.FirstCol; c <= lastCol; c++ {
+				for len(cells[r]) <= c {
+					cells[r] = append(cells[r], Cell{})
+				}
+				cells[r][c] = origin
+			}
+		}
+	}
+
+	sheet := ExcelSheet{Name: g.name, Hidden: g.hidden, Rows: g.rows, Cols: g.cols, Merged: g.merged}
+	for r, row := range cells {
+		if opts.SkipHidden && g.hiddenRows[r] {
+			continue
+		}
+		out := make([]Cell, 0, len(row))
+		for c, cell := range row {
+			cell.Row, cell.Col = r, c
+			cell.Hidden = g.hiddenRows[r] || g.hiddenCols[c]
+			if opts.SkipHidden && cell.Hidden {
+				continue
+			}
+			out = append(out, cell)
+		}
+		sheet.Cells = append(sheet.Cells, out)
+	}
+	return sheet
+}
+
+func xlsxGrid(sheet *xlsx.Sheet, date1904 bool) *sheetGrid {
+	g := &sheetGrid{
+		name:       sheet.Name,
+		hidden:     sheet.Hidden,
+		rows:       sheet.MaxRow,
+		cols:       sheet.MaxCol,
+		hiddenRows: make(map[int]bool),
+		hiddenCols: make(map[int]bool),
+	}
+	for c, col := range sheet.Cols {
+		if col != nil && col.Hidden {
+			g.hiddenCols[c] = true
+		}
+	}
+	for r, row := range sheet.Rows {
+		if row == nil {
+			g.cells = append(g.cells, nil)
+			continue
+		}
+		if row.Hidden {
+			g.hiddenRows[r] = true
+		}
+		cells := make([]Cell, len(row.Cells))
+		for c, cell := range row.Cells {
+			if cell == nil {
+				continue
+			}
+			cells[c] = xlsxCell(cell, date1904)
+			if cell.HMerge > 0 || cell.VMerge > 0 {
+				g.merged = append(g.merged, CellRange{FirstRow: r, FirstCol: c, LastRow: r + cell.VMerge, LastCol: c + cell.HMerge})
+			}
+		}
+		g.cells = append(g.cells, cells)
+	}
+	return g
+}
+
+func xlsxCell(c *xlsx.Cell, date1904 bool) Cell {
+	cell := Cell{Text: c.String(), Formula: c.Formula()}
+	if c.Value == "" {
+		return cell
+	}
+	switch c.Type() {
+	case xlsx.CellTypeNumeric:
+		f, err := c.Float()
+		if err != nil {
+			cell.Type = CellString
+		} else if c.IsTime() {
+			t := xlsx.TimeFromExcelTime(f, date1904)
+			cell.Type, cell.Time = CellDate, &t
+		} else {
+			cell.Type, cell.Number = CellNumber, f
+		}
+	case xlsx.CellTypeBool:
+		cell.Type, cell.Bool = CellBool, c.Bool()
+	case xlsx.CellTypeError:
+		cell.Type = CellError
+	case xlsx.CellTypeDate:
+		// t="d" 的单元格值为 ISO 8601 时间
+		cell.Type = CellString
+		if t := parseOoxmlDate(c.Value); t != nil {
+			cell.Type, cell.Time = CellDate, t
+		}
+	default:
+		cell.Type = CellString
+	}
+	return cell
+}
@@ -0,0 +1,324 @@
+package office
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
+	"github.com/comqositi/toolkits/thirdsdk/filetype"
+	"github.com/ledongthuc/pdf"
+)
+
+// Document 统一的文件提取结果
+type Document struct {
+	Text     string     `json:"text"`               // 文本内容
+	Suffix   string     `json:"suffix"`             // 文件后缀
+	Size     int        `json:"size"`               // 文件大小
+	Mime     string     `json:"mime,omitempty"`     // 按内容识别的 MIME 类型
+	Pages    int        `json:"pages,omitempty"`    // 页数，演示文稿为幻灯片数，表格为工作表数，未知时为 0
+	Title    string     `json:"title,omitempty"`    // 标题
+	Author   string     `json:"author,omitempty"`   // 作者
+	Created  *time.Time `json:"created,omitempty"`  // 创建时间
+	Modified *time.Time `json:"modified,omitempty"` // 最后修改时间
+	Language string     `json:"language,omitempty"` // 文档声明的语言，未声明时按文字粗略判断
+	Encoding string     `json:"encoding,omitempty"` // 文本文件识别出的原始编码，如 gb18030、utf-16le
+	Warnings []string   `json:"warnings,omitempty"` // 不影响提取的问题，如元数据损坏、没有文字层
+}
+
+// Extractor 文件内容提取器
+type Extractor interface {
+	Extract(ctx context.Context, filePath string) (*Document, error)
+}
+
+// ReaderExtractor 可以直接从 io.ReaderAt 提取内容的提取器，ExtractReader 优先使用
+type ReaderExtractor interface {
+	Extractor
+	ExtractReader(ctx context.Context, r io.ReaderAt, size int64) (*Document, error)
+}
+
+// ExtractorFunc 函数形式的提取器
+type ExtractorFunc func(ctx context.Context, filePath string) (*Document, error)
+
+func (f ExtractorFunc) Extract(ctx context.Context, filePath string) (*Document, error) {
+	return f(ctx, filePath)
+}
+
+type registry struct {
+	mu     sync.RWMutex
+	byExt  map[string]Extractor
+	byMime map[string]Extractor
+}
+
+var defaultRegistry = &registry{
+	byExt:  make(map[string]Extractor),
+	byMime: make(map[string]Extractor),
+}
+
+func init() {
+	Register(readerExtractor(wordDocument), []string{filetype.Docx.Ext, filetype.Doc.Ext}, []string{filetype.Docx.Mime, filetype.Doc.Mime})
+	Register(readerExtractor(excelDocument), []string{filetype.Xlsx.Ext, filetype.Xls.Ext}, []string{filetype.Xlsx.Mime, filetype.Xls.Mime})
+	Register(readerExtractor(pptDocument), []string{filetype.Pptx.Ext, filetype.Ppt.Ext}, []string{filetype.Pptx.Mime, filetype.Ppt.Mime})
+	Register(readerExtractor(pdfDocument), []string{filetype.Pdf.Ext}, []string{filetype.Pdf.Mime})
+	Register(readerExtractor(txtDocument), []string{filetype.Txt.Ext}, []string{filetype.Txt.Mime})
+	Register(readerExtractor(csvDocument), []string{filetype.Csv.Ext, filetype.Tsv.Ext}, []string{filetype.Csv.Mime, filetype.Tsv.Mime})
+}
+
+// Register 按文件后缀和 MIME 类型注册提取器，重复注册时后者覆盖前者
+func Register(e Extractor, exts []string, mimes []string) {
+	defaultRegistry.mu.Lock()
+	defer defaultRegistry.mu.Unlock()
+	for _, ext := range exts {
+		defaultRegistry.byExt[normalizeExt(ext)] = e
+	}
+	for _, m := range mimes {
+		defaultRegistry.byMime[strings.ToLower(m)] = e
+	}
+}
+
+// Lookup 按文件后缀查找提取器
+func Lookup(ext string) (Extractor, bool) {
+	defaultRegistry.mu.RLock()
+	defer defaultRegistry.mu.RUnlock()
+	e, ok := defaultRegistry.byExt[normalizeExt(ext)]
+	return e, ok
+}
+
+// LookupMime 按 MIME 类型查找提取器
+func LookupMime(mime string) (Extractor, bool) {
+	defaultRegistry.mu.RLock()
+	defer defaultRegistry.mu.RUnlock()
+	e, ok := defaultRegistry.byMime[strings.ToLower(mime)]
+	return e, ok
+}
+
+// Extract 根据文件内容识别格式并自动选择提取器，source 可以是本地路径或 http(s) 地址
+func Extract(ctx context.Context, source string) (*Document, error) {
+	filePath := source
+	if isUrl(source) {
+		suffix, _ := getSuffix(source)
+		localPath, err := saveFile(ctx, source, suffix)
+		if err != nil {
+			return nil, errorx.Wrap(errorx.ErrDownload, err)
+		}
+		defer os.Remove(localPath)
+		filePath = localPath
+	}
+
+	t, err := detectType(filePath, source)
+	if err != nil {
+		return nil, errorx.Wrap(errorx.ErrUnsupportedFormat, err)
+	}
+	e, ok := lookupType(t)
+	if !ok {
+		return nil, errorx.ErrUnsupportedFormat
+	}
+	if re, ok := e.(readerExtractor); ok {
+		return re.extractFile(ctx, filePath, t)
+	}
+	return e.Extract(ctx, filePath)
+}
+
+// ExtractReader 同 Extract，从 r 中按内容识别格式后提取；
+// 提取器不支持 io.ReaderAt 时先写入临时文件
+func ExtractReader(ctx context.Context, r io.ReaderAt, size int64) (*Document, error) {
+	t, err := filetype.Detect(r, size)
+	if err != nil || t.IsUnknown() {
+		return nil, errorx.ErrUnsupportedFormat
+	}
+	e, ok := lookupType(t)
+	if !ok {
+		return nil, errorx.ErrUnsupportedFormat
+	}
+	if re, ok := e.(readerExtractor); ok {
+		return re.extract(ctx, r, size, t)
+	}
+	if re, ok := e.(ReaderExtractor); ok {
+		return re.ExtractReader(ctx, r, size)
+	}
+
+	tmpFile, err := os.CreateTemp("", "extract-*."+t.Ext)
+	if err != nil {
+		return nil, errorx.Wrap(errorx.ErrTempFile, err)
+	}
+	defer os.Remove(tmpFile.Name())
+	_, err = io.Copy(tmpFile, io.NewSectionReader(r, 0, size))
+	if cerr := tmpFile.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, errorx.Wrap(errorx.ErrTempFile, err)
+	}
+	return e.Extract(ctx, tmpFile.Name())
+}
+
+// ExtractBytes 同 ExtractReader，读取内存中的文件
+func ExtractBytes(ctx context.Context, data []byte) (*Document, error) {
+	return ExtractReader(ctx, bytes.NewReader(data), int64(len(data)))
+}
+
+func lookupType(t filetype.Type) (Extractor, bool) {
+	if e, ok := LookupMime(t.Mime); ok {
+		return e, true
+	}
+	return Lookup(t.Ext)
+}
+
+// readerExtractor 从 io.ReaderAt 生成 Document 的内置提取器，t 为已识别的格式，未识别时为 Unknown
+type readerExtractor func(r io.ReaderAt, size int64, t filetype.Type) (*Document, error)
+
+func (fn readerExtractor) Extract(ctx context.Context, filePath string) (*Document, error) {
+	t, _ := detectType(filePath, filePath)
+	return fn.extractFile(ctx, filePath, t)
+}
+
+func (fn readerExtractor) ExtractReader(ctx context.Context, r io.ReaderAt, size int64) (*Document, error) {
+	t, _ := filetype.Detect(r, size)
+	return fn.extract(ctx, r, size, t)
+}
+
+func (fn readerExtractor) extractFile(ctx context.Context, filePath string, t filetype.Type) (doc *Document, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	err = readLocalFile(filePath, func(r io.ReaderAt, size int64) error {
+		doc, err = fn.extract(ctx, r, size, t)
+		return err
+	})
+	return doc, err
+}
+
+func (fn readerExtractor) extract(ctx context.Context, r io.ReaderAt, size int64, t filetype.Type) (*Document, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	doc, err := fn(r, size, t)
+	if err != nil {
+		return nil, err
+	}
+	if doc.Language == "" {
+		doc.Language = guessLanguage(doc.Text)
+	}
+	return doc, nil
+}
+
+// newDocument 使用已识别的格式 t，未识别时使用 fallback
+func newDocument(t filetype.Type, size int64, fallback filetype.Type, text string) *Document {
+	if t.IsUnknown() {
+		t = fallback
+	}
+	return &Document{Text: text, Suffix: t.Ext, Size: int(size), Mime: t.Mime}
+}
+
+func wordDocument(r io.ReaderAt, size int64, t filetype.Type) (*Document, error) {
+	text, err := parseWordText(r, size, t, WordOptions{})
+	if err != nil {
+		return nil, err
+	}
+	doc := newDocument(t, size, filetype.Docx, text)
+	if doc.Suffix == filetype.Docx.Ext {
+		doc.Pages = readOoxmlMeta(r, size, doc).Pages
+	}
+	return doc, nil
+}
+
+// excelDocument 每个工作表输出表名，每行单元格以制表符分隔
+func excelDocument(r io.ReaderAt, size int64, t filetype.Type) (*Document, error) {
+	sheets, err := parseExcel(r, size, t)
+	if err != nil {
+		return nil, err
+	}
+	var sb strings.Builder
+	for _, sheet := range sheets {
+		sb.WriteString(sheet.Name)
+		sb.WriteString("\n")
+		for _, row := range sheet.Content {
+			sb.WriteString(strings.Join(row, "\t"))
+			sb.WriteString("\n")
+		}
+	}
+	doc := newDocument(t, size, filetype.Xlsx, sb.String())
+	if doc.Suffix == filetype.Xlsx.Ext {
+		readOoxmlMeta(r, size, doc)
+	}
+	doc.Pages = len(sheets)
+	return doc, nil
+}
+
+func pptDocument(r io.ReaderAt, size int64, t filetype.Type) (*Document, error) {
+	slides, err := parseSlides(r, size, t)
+	if err != nil {
+		return nil, err
+	}
+	doc := newDocument(t, size, filetype.Pptx, slidesText(slides))
+	if doc.Suffix == filetype.Pptx.Ext {
+		readOoxmlMeta(r, size, doc)
+	}
+	doc.Pages = len(slides)
+	return doc, nil
+}
+
+func pdfDocument(ra io.ReaderAt, size int64, t filetype.Type) (*Document, error) {
+	r, err := pdf.NewReader(ra, size)
+	if err != nil {
+		return nil, errorx.Wrap(errorx.ErrParse, err)
+	}
+	text, err := pdfPlainText(r)
+	if err != nil {
+		return nil, errorx.Wrap(errorx.ErrParse, err)
+	}
+	doc := newDocument(t, size, filetype.Pdf, text)
+	doc.Pages = r.NumPage()
+	readPdfInfo(r, doc)
+	if strings.TrimSpace(text) == "" && doc.Pages > 0 {
+		doc.Warnings = append(doc.Warnings, "no text layer found, the pdf may be scanned; use PdfToContentWithOcr")
+	}
+	return doc, nil
+}
+
+func txtDocument(r io.ReaderAt, size int64, t filetype.Type) (*Document, error) {
+	text, encoding, err := parseTxt(r, size)
+	if err != nil {
+		return nil, err
+	}
+	doc := newDocument(t, size, filetype.Txt, text)
+	doc.Encoding = encoding
+	return doc, nil
+}
+
+// csvDocument 每行单元格以制表符分隔
+func csvDocument(r io.ReaderAt, size int64, t filetype.Type) (*Document, error) {
+	cr, err := NewCsvReader(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return nil, err
+	}
+	rows, err := cr.readAll()
+	if err != nil {
+		return nil, err
+	}
+	var sb strings.Builder
+	for _, row := range rows {
+		sb.WriteString(strings.Join(row, "\t"))
+		sb.WriteString("\n")
+	}
+	t = filetype.Csv
+	if cr.dialect.Delimiter == '\t' {
+		t = filetype.Tsv
+	}
+	doc := &Document{Text: sb.String(), Suffix: t.Ext, Size: int(size), Mime: t.Mime}
+	doc.Encoding = cr.dialect.Encoding
+	return doc, nil
+}
+
+func normalizeExt(ext string) string {
+	return strings.ToLower(strings.TrimPrefix(ext, "."))
+}
+
+func isUrl(source string) bool {
+	s := strings.ToLower(source)
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
@@ -0,0 +1,387 @@
+package office
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
+	"github.com/comqositi/toolkits/thirdsdk/filetype"
+)
+
+var (
+	ErrFaqHeader    = errorx.New(errorx.ErrInvalidInput, "question or answer header not found")
+	ErrFaqQuestion  = errorx.New(errorx.ErrInvalidInput, "missing question")
+	ErrFaqAnswer    = errorx.New(errorx.ErrInvalidInput, "missing answer")
+	ErrFaqTooLong   = errorx.New(errorx.ErrInvalidInput, "text too long")
+	ErrFaqDuplicate = errorx.New(errorx.ErrInvalidInput, "duplicate question")
+)
+
+// 查找表头时最多扫描的行数
+const faqHeaderScan = 10
+
+// FaqItem 导入的一条问答
+type FaqItem struct {
+	Sheet    string            `json:"sheet,omitempty"`    // 所在工作表，csv 为空
+	Row      int               `json:"row"`                // 行号，从 1 开始，与表格软件中显示的一致
+	Question string            `json:"question"`           // 问题
+	Answers  []string          `json:"answers"`            // 答案，多个答案列按表头顺序排列
+	Category string            `json:"category,omitempty"` // 分类
+	Tags     []string          `json:"tags,omitempty"`     // 标签
+	Extra    map[string]string `json:"extra,omitempty"`    // 其余列，键为表头
+}
+
+// FaqRowError 未通过校验的行，Err 为 ErrFaqQuestion 等错误，Reason 为其文本
+type FaqRowError struct {
+	Sheet  string `json:"sheet,omitempty"`
+	Row    int    `json:"row"`
+	Reason string `json:"reason"`
+	Err    error  `json:"-"`
+}
+
+func newFaqRowError(sheet string, row int, err error) *FaqRowError {
+	return &FaqRowError{Sheet: sheet, Row: row, Reason: err.Error(), Err: err}
+}
+
+func (e *FaqRowError) Error() string {
+	if e.Sheet != "" {
+		return fmt.Sprintf("sheet %s row %d: %v", e.Sheet, e.Row, e.Err)
+	}
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+func (e *FaqRowError) Unwrap() error {
+	return e.Err
+}
+
+// FaqResult 问答导入结果，未通过校验的行不会出现在 Items 中
+type FaqResult struct {
+	Items  []FaqItem      `json:"items"`
+	Errors []*FaqRowError `json:"errors,omitempty"`
+}
+
+// FaqOptions 问答导入选项，表头按名称匹配，忽略大小写和首尾空白
+type FaqOptions struct {
+	QuestionHeaders []string // 问题列的表头，默认为 问题、标准问题、Q、Question
+	AnswerHeaders   []string // 答案列的表头，默认为 答案、回答、A、Answer；答案1、Answer 2 这类带序号的表头也会匹配，可以有多列
+	CategoryHeaders []string // 分类列的表头，默认为 分类、类别、Category
+	TagHeaders      []string // 标签列的表头，默认为 标签、Tag、Tags
+	TagSeparators   string   // 标签的分隔符，默认为中英文逗号、分号、顿号和竖线
+	NoHeader        bool     // 没有表头，第一列为问题，第二列为答案
+	MaxQuestionLen  int      // 问题的最大字数，0 不限制
+	MaxAnswerLen    int      // 每个答案的最大字数，0 不限制
+	SkipHidden      bool     // 跳过表格中隐藏的工作表、行和列
+}
+
+func (o FaqOptions) withDefaults() FaqOptions {
+	if len(o.QuestionHeaders) == 0 {
+		o.QuestionHeaders = []string{"问题", "标准问题", "q", "question"}
+	}
+	if len(o.AnswerHeaders) == 0 {
+		o.AnswerHeaders = []string{"答案", "回答", "a", "answer"}
+	}
+	if len(o.CategoryHeaders) == 0 {
+		o.CategoryHeaders = []string{"分类", "类别", "category"}
+	}
+	if len(o.TagHeaders) == 0 {
+		o.TagHeaders = []string{"标签", "tag", "tags"}
+	}
+	if o.TagSeparators == "" {
+		o.TagSeparators = ",，;；、|"
+	}
+	return o
+}
+
+// ExcelToFaq 从 xlsx 或 xls 中导入问答，每个工作表单独查找表头
+func ExcelToFaq(filePath string, opts FaqOptions) (res *FaqResult, fileSuffix string, FileSize int, err error) {
+	t, err := detectType(filePath, filePath)
+	if err != nil {
+		return nil, "", 0, errorx.Wrap(errorx.ErrUnsupportedFormat, err)
+	}
+	size, err := countSize(filePath)
+	if err != nil {
+		return nil, "", 0, errorx.Wrap(errorx.ErrRead, err)
+	}
+	err = readLocalFile(filePath, func(r io.ReaderAt, size int64) error {
+		res, err = parseExcelFaq(r, size, t, opts)
+		return err
+	})
+	if err != nil {
+		return nil, "", 0, err
+	}
+	return res, t.Ext, size, nil
+}
+
+// ExcelUrlToFaq 同 ExcelToFaq，读取 url 文件
+func ExcelUrlToFaq(url string, opts FaqOptions) (res *FaqResult, fileSuffix string, FileSize int, err error) {
+	return ExcelUrlToFaqCtx(context.Background(), url, opts)
+}
+
+// ExcelUrlToFaqCtx 同 ExcelUrlToFaq，ctx 取消时中止请求
+func ExcelUrlToFaqCtx(ctx context.Context, url string, opts FaqOptions) (res *FaqResult, fileSuffix string, FileSize int, err error) {
+	suffix, _ := getSuffix(url)
+	filePath, err := saveFile(ctx, url, suffix)
+	if err != nil {
+		return nil, "", 0, errorx.Wrap(errorx.ErrDownload, err)
+	}
+	defer os.Remove(filePath)
+
+	return ExcelToFaq(filePath, opts)
+}
+
+// ExcelFaqFromReader 同 ExcelToFaq，从 r 中读取，不落盘
+func ExcelFaqFromReader(r io.ReaderAt, size int64, opts FaqOptions) (res *FaqResult, fileSuffix string, FileSize int, err error) {
+	t, err := readerType(r, size)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	res, err = parseExcelFaq(r, size, t, opts)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	return res, t.Ext, int(size), nil
+}
+
+// CsvToFaq 从 csv 或 tsv 中导入问答，编码、分隔符和引号自动识别
+func CsvToFaq(filePath string, opts FaqOptions) (res *FaqResult, fileSuffix string, FileSize int, err error) {
+	suffix, err := detectSuffix(filePath, filePath)
+	if err != nil {
+		return nil, "", 0, errorx.Wrap(errorx.ErrUnsupportedFormat, err)
+	}
+	size, err := countSize(filePath)
+	if err != nil {
+		return nil, "", 0, errorx.Wrap(errorx.ErrRead, err)
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, "", 0, errorx.Wrap(errorx.ErrRead, err)
+	}
+	defer f.Close()
+	res, err = CsvFaqFromReader(f, opts)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	return res, suffix, size, nil
+}
+
+// CsvUrlToFaq 同 CsvToFaq，读取 url 文件
+func CsvUrlToFaq(url string, opts FaqOptions) (res *FaqResult, fileSuffix string, FileSize int, err error) {
+	return CsvUrlToFaqCtx(context.Background(), url, opts)
+}
+
+// CsvUrlToFaqCtx 同 CsvUrlToFaq，ctx 取消时中止请求
+func CsvUrlToFaqCtx(ctx context.Context, url string, opts FaqOptions) (res *FaqResult, fileSuffix string, FileSize int, err error) {
+	suffix, _ := getSuffix(url)
+	filePath, err := saveFile(ctx, url, suffix)
+	if err != nil {
+		return nil, "", 0, errorx.Wrap(errorx.ErrDownload, err)
+	}
+	defer os.Remove(filePath)
+
+	return CsvToFaq(filePath, opts)
+}
+
+// CsvFaqFromReader 同 CsvToFaq，从 r 中读取
+func CsvFaqFromReader(r io.Reader, opts FaqOptions) (*FaqResult, error) {
+	rows, err := readCsvRows(r)
+	if err != nil {
+		return nil, err
+	}
+	res := &FaqResult{}
+	opts.withDefaults().parseRows("", rows, nil, res, make(map[string]FaqItem))
+	return res, nil
+}
+
+func parseExcelFaq(r io.ReaderAt, size int64, t filetype.Type, opts FaqOptions) (*FaqResult, error) {
+	sheets, err := parseExcelSheets(r, size, t, ExcelOptions{SkipHidden: opts.SkipHidden})
+	if err != nil {
+		return nil, err
+	}
+	opts = opts.withDefaults()
+	res := &FaqResult{}
+	seen := make(map[string]FaqItem)
+	for _, sheet := range sheets {
+		rows := make([][]string, len(sheet.Cells))
+		// 跳过隐藏行后下标与原始行号不一致，行号取自单元格的原始位置
+		lines := make([]int, len(sheet.Cells))
+		for i, cells := range sheet.Cells {
+			rows[i] = make([]string, len(cells))
+			for j, cell := range cells {
+				rows[i][j] = cell.Text
+			}
+			lines[i] = i + 1
+			if len(cells) > 0 {
+				lines[i] = cells[0].Row + 1
+			}
+		}
+		opts.parseRows(sheet.Name, rows, lines, res, seen)
+	}
+	return res, nil
+}
+
+// readCsvRows 读取 csv 的所有行，编码、分隔符和引号自动识别
+func readCsvRows(r io.Reader) ([][]string, error) {
+	cr, err := NewCsvReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return cr.readAll()
+}
+
+// faqColumns 表头对应的列号
+type faqColumns struct {
+	question int
+	answers  []int
+	category int
+	tags     int
+	extra    map[int]string
+}
+
+// findHeader 在前几行中查找同时有问题列和答案列的表头，返回表头所在行
+func (o FaqOptions) findHeader(rows [][]string) (int, *faqColumns) {
+	for i := 0; i < len(rows) && i < faqHeaderScan; i++ {
+		cols := &faqColumns{question: -1, category: -1, tags: -1, extra: make(map[int]string)}
+		for j, cell := range rows[i] {
+			name := strings.ToLower(strings.TrimSpace(cell))
+			switch {
+			case name == "":
+			case cols.question < 0 && matchHeader(name, o.QuestionHeaders, false):
+				cols.question = j
+			case matchHeader(name, o.AnswerHeaders, true):
+				cols.answers = append(cols.answers, j)
+			case cols.category < 0 && matchHeader(name, o.CategoryHeaders, false):
+				cols.category = j
+			case cols.tags < 0 && matchHeader(name, o.TagHeaders, false):
+				cols.tags = j
+			default:
+				cols.extra[j] = strings.TrimSpace(cell)
+			}
+		}
+		if cols.question >= 0 && len(cols.answers) > 0 {
+			return i, cols
+		}
+	}
+	return -1, nil
+}
+
+// matchHeader numbered 为 true 时忽略表头末尾的序号，如 答案2、Answer (3)
+func matchHeader(name string, headers []string, numbered bool) bool {
+	if numbered {
+		name = strings.TrimRightFunc(name, func(r rune) bool {
+			return unicode.IsDigit(r) || unicode.IsSpace(r) || strings.ContainsRune("-_#()（）", r)
+		})
+	}
+	for _, h := range headers {
+		if strings.EqualFold(name, strings.TrimSpace(h)) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRows 解析一个工作表，lines 为各行在文件中的行号，为 nil 时按下标计算；seen 记录已导入的问题，用于跨工作表查重
+func (o FaqOptions) parseRows(sheet string, rows [][]string, lines []int, res *FaqResult, seen map[string]FaqItem) {
+	start, cols := -1, &faqColumns{question: 0, answers: []int{1}, category: -1, tags: -1}
+	if !o.NoHeader {
+		start, cols = o.findHeader(rows)
+		if cols == nil {
+			if len(rows) > 0 {
+				res.Errors = append(res.Errors, newFaqRowError(sheet, 1, ErrFaqHeader))
+			}
+			return
+		}
+	}
+
+	for i := start + 1; i < len(rows); i++ {
+		row := rows[i]
+		if isBlankRow(row) {
+			continue
+		}
+		line := i + 1
+		if lines != nil {
+			line = lines[i]
+		}
+		item := FaqItem{Sheet: sheet, Row: line, Question: rowCell(row, cols.question), Category: rowCell(row, cols.category)}
+		for _, c := range cols.answers {
+			if answer := rowCell(row, c); answer != "" {
+				item.Answers = append(item.Answers, answer)
+			}
+		}
+		if tags := rowCell(row, cols.tags); tags != "" {
+			item.Tags = splitTags(tags, o.TagSeparators)
+		}
+		for c, name := range cols.extra {
+			if v := rowCell(row, c); v != "" {
+				if item.Extra == nil {
+					item.Extra = make(map[string]string)
+				}
+				item.Extra[name] = v
+			}
+		}
+
+		if err := o.validate(item, seen); err != nil {
+			res.Errors = append(res.Errors, newFaqRowError(sheet, item.Row, err))
+			continue
+		}
+		seen[item.Question] = item
+		res.Items = append(res.Items, item)
+	}
+}
+
+func (o FaqOptions) validate(item FaqItem, seen map[string]FaqItem) error {
+	if item.Question == "" {
+		return ErrFaqQuestion
+	}
+	if len(item.Answers) == 0 {
+		return ErrFaqAnswer
+	}
+	if o.MaxQuestionLen > 0 && utf8.RuneCountInString(item.Question) > o.MaxQuestionLen {
+		return fmt.Errorf("%w: question exceeds %d characters", ErrFaqTooLong, o.MaxQuestionLen)
+	}
+	for _, answer := range item.Answers {
+		if o.MaxAnswerLen > 0 && utf8.RuneCountInString(answer) > o.MaxAnswerLen {
+			return fmt.Errorf("%w: answer exceeds %d characters", ErrFaqTooLong, o.MaxAnswerLen)
+		}
+	}
+	if first, ok := seen[item.Question]; ok {
+		if first.Sheet != "" && first.Sheet != item.Sheet {
+			return fmt.Errorf("%w: first seen at sheet %s row %d", ErrFaqDuplicate, first.Sheet, first.Row)
+		}
+		return fmt.Errorf("%w: first seen at row %d", ErrFaqDuplicate, first.Row)
+	}
+	return nil
+}
+
+func rowCell(row []string, col int) string {
+	if col < 0 || col >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[col])
+}
+
+func isBlankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// splitTags 按分隔符拆分标签，去掉空白和重复
+func splitTags(s string, separators string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, tag := range strings.FieldsFunc(s, func(r rune) bool { return strings.ContainsRune(separators, r) }) {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
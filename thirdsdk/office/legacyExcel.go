@@ -0,0 +1,576 @@
+package office
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/tealeg/xlsx"
+
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
+	"github.com/comqositi/toolkits/thirdsdk/internal/ole2"
+)
+
+// Excel 97-2003 二进制格式（.xls，BIFF8），读取单元格的值、公式、合并区域和隐藏的行列
+
+const (
+	biffFormula     = 0x0006
+	biffEof         = 0x000A
+	biffExternSheet = 0x0017
+	biffDateMode    = 0x0022
+	biffFilePass    = 0x002F
+	biffContinue    = 0x003C
+	biffColInfo     = 0x007D
+	biffBoundSheet  = 0x0085
+	biffMulRk       = 0x00BD
+	biffRString     = 0x00D6
+	biffSst         = 0x00FC
+	biffMergeCells  = 0x00E5
+	biffLabelSst    = 0x00FD
+	biffXf          = 0x00E0
+	biffSupBook     = 0x01AE
+	biffFormat      = 0x041E
+	biffNumber      = 0x0203
+	biffLabel       = 0x0204
+	biffRow         = 0x0208
+	biffBoolErr     = 0x0205
+	biffString      = 0x0207
+	biffRk          = 0x027E
+	biffBof         = 0x0809
+
+	biffSubstreamWorksheet = 0x0010
+)
+
+type biffRecord struct {
+	id     uint16
+	offset int
+	data   []byte
+	// 紧随其后的 CONTINUE 记录
+	continues [][]byte
+}
+
+// xlsBook 工作簿级别的信息：数字格式用于识别日期，外部引用用于还原公式中的工作表名
+type xlsBook struct {
+	formats  map[int]string
+	xfs      []int
+	dates    map[int]bool
+	date1904 bool
+	// supBooks 各 SUPBOOK 是否指向本工作簿
+	supBooks   []bool
+	xti        []xlsXti
+	sheetNames []string
+}
+
+type xlsSheet struct {
+	name       string
+	offset     uint32
+	hidden     bool
+	cells      map[int]map[int]Cell
+	maxRow     int
+	maxCol     int
+	merged     []CellRange
+	hiddenRows map[int]bool
+	hiddenCols map[int]bool
+}
+
+func readXlsBinary(r io.ReaderAt, size int64) ([]ExcelResult, error) {
+	sheets, err := readXlsSheets(r, size)
+	if err != nil {
+		return nil, err
+	}
+	var res []ExcelResult
+	for _, s := range sheets {
+		res = append(res, ExcelResult{Name: s.name, Content: s.table()})
+	}
+	return res, nil
+}
+
+func readXlsSheets(r io.ReaderAt, size int64) ([]*xlsSheet, error) {
+	ole, err := ole2.Open(r, size)
+	if err != nil {
+		return nil, err
+	}
+	name := "Workbook"
+	if !ole.HasStream(name) {
+		name = "Book"
+	}
+	stream, err := ole.Stream(name)
+	if err != nil {
+		return nil, err
+	}
+
+	records := splitBiffRecords(stream)
+	book := &xlsBook{formats: make(map[int]string), dates: make(map[int]bool)}
+	var (
+		sst    []string
+		sheets []*xlsSheet
+		cur    *xlsSheet
+		// 公式结果为字符串时，值在随后的 STRING 记录中
+		pendingRow, pendingCol = -1, -1
+		pendingFormula         string
+	)
+	for _, rec := range records {
+		switch rec.id {
+		case biffFilePass:
+			return nil, errorx.ErrEncrypted
+		case biffFormat:
+			if len(rec.data) > 4 {
+				s, _ := readBiffString(rec.data[2:])
+				book.formats[int(binary.LittleEndian.Uint16(rec.data))] = s
+			}
+		case biffXf:
+			if len(rec.data) >= 4 {
+				book.xfs = append(book.xfs, int(binary.LittleEndian.Uint16(rec.data[2:])))
+			}
+		case biffDateMode:
+			book.date1904 = len(rec.data) >= 2 && binary.LittleEndian.Uint16(rec.data) == 1
+		case biffSupBook:
+			// cch 为 0x0401 表示本工作簿
+			book.supBooks = append(book.supBooks, len(rec.data) >= 4 && binary.LittleEndian.Uint16(rec.data[2:]) == 0x0401)
+		case biffExternSheet:
+			book.addXti(rec.data)
+		case biffBoundSheet:
+			if len(rec.data) < 8 {
+				continue
+			}
+			// 公式中的工作表下标包含图表等所有工作表
+			name, _ := readBiffShortString(rec.data[6:])
+			book.sheetNames = append(book.sheetNames, name)
+			if rec.data[5] != 0 {
+				continue
+			}
+			sheets = append(sheets, &xlsSheet{
+				name:       name,
+				offset:     binary.LittleEndian.Uint32(rec.data),
+				hidden:     rec.data[4]&0x03 != 0,
+				cells:      make(map[int]map[int]Cell),
+				maxRow:     -1,
+				maxCol:     -1,
+				hiddenRows: make(map[int]bool),
+				hiddenCols: make(map[int]bool),
+			})
+		case biffSst:
+			sst = parseSst(rec)
+		case biffBof:
+			cur = nil
+			if len(rec.data) >= 4 && binary.LittleEndian.Uint16(rec.data[2:]) == biffSubstreamWorksheet {
+				for _, s := range sheets {
+					if int(s.offset) == rec.offset {
+						cur = s
+					}
+				}
+			}
+		case biffEof:
+			cur = nil
+		case biffString:
+			if cur != nil && pendingRow >= 0 {
+				s, _ := readBiffString(rec.data)
+				cell := stringCell(s)
+				cell.Formula = pendingFormula
+				cur.set(pendingRow, pendingCol, cell)
+			}
+			pendingRow, pendingCol, pendingFormula = -1, -1, ""
+		case biffMergeCells:
+			if cur != nil {
+				cur.addMerged(rec.data)
+			}
+		case biffColInfo:
+			// colFirst、colLast、coldx、ixfe 之后为选项，最低位表示隐藏
+			if cur != nil && len(rec.data) >= 10 && rec.data[8]&0x01 != 0 {
+				first, last := int(binary.LittleEndian.Uint16(rec.data)), int(binary.LittleEndian.Uint16(rec.data[2:]))
+				for c := first; c <= last && c < 256; c++ {
+					cur.hiddenCols[c] = true
+				}
+			}
+		case biffRow:
+			// 选项中的 fDyZero 表示行被隐藏
+			if cur != nil && len(rec.data) >= 16 && binary.LittleEndian.Uint32(rec.data[12:])&0x20 != 0 {
+				cur.hiddenRows[int(binary.LittleEndian.Uint16(rec.data))] = true
+			}
+		}
+		if cur == nil || len(rec.data) < 6 {
+			continue
+		}
+
+		row := int(binary.LittleEndian.Uint16(rec.data))
+		col := int(binary.LittleEndian.Uint16(rec.data[2:]))
+		xf := int(binary.LittleEndian.Uint16(rec.data[4:]))
+		switch rec.id {
+		case biffLabelSst:
+			if len(rec.data) >= 10 {
+				idx := int(binary.LittleEndian.Uint32(rec.data[6:]))
+				if idx < len(sst) {
+					cur.set(row, col, stringCell(sst[idx]))
+				}
+			}
+		case biffLabel, biffRString:
+			if len(rec.data) > 8 {
+				s, _ := readBiffString(rec.data[6:])
+				cur.set(row, col, stringCell(s))
+			}
+		case biffNumber:
+			if len(rec.data) >= 14 {
+				cur.set(row, col, book.numberCell(xf, math.Float64frombits(binary.LittleEndian.Uint64(rec.data[6:]))))
+			}
+		case biffRk:
+			if len(rec.data) >= 10 {
+				cur.set(row, col, book.numberCell(xf, decodeRk(binary.LittleEndian.Uint32(rec.data[6:]))))
+			}
+		case biffMulRk:
+			// colFirst 之后为若干 (ixfe, rk)，最后 2 字节为 colLast
+			for i, c := 4, col; i+6 <= len(rec.data)-2; i, c = i+6, c+1 {
+				xf := int(binary.LittleEndian.Uint16(rec.data[i:]))
+				cur.set(row, c, book.numberCell(xf, decodeRk(binary.LittleEndian.Uint32(rec.data[i+2:]))))
+			}
+		case biffBoolErr:
+			if len(rec.data) >= 8 && rec.data[7] == 0 {
+				cur.set(row, col, boolCell(rec.data[6] != 0))
+			}
+		case biffFormula:
+			if len(rec.data) < 14 {
+				continue
+			}
+			formula := book.formula(rec.data)
+			val := rec.data[6:14]
+			if binary.LittleEndian.Uint16(val[6:]) != 0xFFFF {
+				cell := book.numberCell(xf, math.Float64frombits(binary.LittleEndian.Uint64(val)))
+				cell.Formula = formula
+				cur.set(row, col, cell)
+				continue
+			}
+			switch val[0] {
+			case 0:
+				pendingRow, pendingCol, pendingFormula = row, col, formula
+			case 1:
+				cell := boolCell(val[2] != 0)
+				cell.Formula = formula
+				cur.set(row, col, cell)
+			}
+		}
+	}
+
+	return sheets, nil
+}
+
+func splitBiffRecords(stream []byte) []biffRecord {
+	var records []biffRecord
+	for off := 0; off+4 <= len(stream); {
+		id := binary.LittleEndian.Uint16(stream[off:])
+		n := int(binary.LittleEndian.Uint16(stream[off+2:]))
+		if off+4+n > len(stream) {
+			break
+		}
+		data := stream[off+4 : off+4+n]
+		if id == biffContinue && len(records) > 0 {
+			last := &records[len(records)-1]
+			last.continues = append(last.continues, data)
+		} else {
+			records = append(records, biffRecord{id: id, offset: off, data: data})
+		}
+		off += 4 + n
+	}
+	return records
+}
+
+func (s *xlsSheet) set(row, col int, v Cell) {
+	if v.Text == "" {
+		return
+	}
+	if s.cells[row] == nil {
+		s.cells[row] = make(map[int]Cell)
+	}
+	s.cells[row][col] = v
+	if row > s.maxRow {
+		s.maxRow = row
+	}
+	if col > s.maxCol {
+		s.maxCol = col
+	}
+}
+
+// addMerged 读取 MERGEDCELLS 记录，2 字节个数之后为若干 (rwFirst, rwLast, colFirst, colLast)
+func (s *xlsSheet) addMerged(data []byte) {
+	if len(data) < 2 {
+		return
+	}
+	n := int(binary.LittleEndian.Uint16(data))
+	for i := 2; i+8 <= len(data) && n > 0; i, n = i+8, n-1 {
+		s.merged = append(s.merged, CellRange{
+			FirstRow: int(binary.LittleEndian.Uint16(data[i:])),
+			LastRow:  int(binary.LittleEndian.Uint16(data[i+2:])),
+			FirstCol: int(binary.LittleEndian.Uint16(data[i+4:])),
+			LastCol:  int(binary.LittleEndian.Uint16(data[i+6:])),
+		})
+	}
+}
+
+// grid 只为有数据的行分配单元格，每行宽度取该行最大列号，避免零散的远端单元格撑出稠密网格
+func (s *xlsSheet) grid() *sheetGrid {
+	g := &sheetGrid{
+		name:       s.name,
+		hidden:     s.hidden,
+		rows:       s.maxRow + 1,
+		cols:       s.maxCol + 1,
+		merged:     s.merged,
+		hiddenRows: s.hiddenRows,
+		hiddenCols: s.hiddenCols,
+	}
+	g.cells = make([][]Cell, s.maxRow+1)
+	for r, cells := range s.cells {
+		maxCol := -1
+		for c := range cells {
+			if c > maxCol {
+				maxCol = c
+			}
+		}
+		row := make([]Cell, maxCol+1)
+		for c, v := range cells {
+			row[c] = v
+		}
+		g.cells[r] = row
+	}
+	return g
+}
+
+func (s *xlsSheet) table() [][]string {
+	var table [][]string
+	for r := 0; r <= s.maxRow; r++ {
+		maxCol := -1
+		for c := range s.cells[r] {
+			if c > maxCol {
+				maxCol = c
+			}
+		}
+		row := make([]string, maxCol+1)
+		for c, v := range s.cells[r] {
+			row[c] = v.Text
+		}
+		table = append(table, row)
+	}
+	return table
+}
+
+func decodeRk(rk uint32) float64 {
+	var v float64
+	if rk&0x02 != 0 {
+		v = float64(int32(rk) >> 2)
+	} else {
+		v = math.Float64frombits(uint64(rk&0xFFFFFFFC) << 32)
+	}
+	if rk&0x01 != 0 {
+		v /= 100
+	}
+	return v
+}
+
+// addXti 读取 EXTERNSHEET 记录，2 字节个数之后为若干 (iSupBook, itabFirst, itabLast)
+func (b *xlsBook) addXti(data []byte) {
+	if len(data) < 2 {
+		return
+	}
+	n := int(binary.LittleEndian.Uint16(data))
+	for i := 2; i+6 <= len(data) && n > 0; i, n = i+6, n-1 {
+		b.xti = append(b.xti, xlsXti{
+			supBook: int(binary.LittleEndian.Uint16(data[i:])),
+			first:   int(int16(binary.LittleEndian.Uint16(data[i+2:]))),
+			last:    int(int16(binary.LittleEndian.Uint16(data[i+4:]))),
+		})
+	}
+}
+
+// xlsBuiltinDates 内置的日期时间格式编号，27-36、50-58 为中日韩区域的日期格式
+var xlsBuiltinDates = map[int]bool{
+	14: true, 15: true, 16: true, 17: true, 18: true, 19: true, 20: true, 21: true, 22: true,
+	27: true, 28: true, 29: true, 30: true, 31: true, 32: true, 33: true, 34: true, 35: true, 36: true,
+	45: true, 46: true, 47: true,
+	50: true, 51: true, 52: true, 53: true, 54: true, 55: true, 56: true, 57: true, 58: true,
+}
+
+// isDate 按单元格 XF 引用的数字格式判断是否为日期时间
+func (b *xlsBook) isDate(xf int) bool {
+	if xf < 0 || xf >= len(b.xfs) {
+		return false
+	}
+	ifmt := b.xfs[xf]
+	if xlsBuiltinDates[ifmt] {
+		return true
+	}
+	format, ok := b.formats[ifmt]
+	if !ok {
+		return false
+	}
+	date, ok := b.dates[ifmt]
+	if !ok {
+		date = (&xlsx.Cell{NumFmt: format}).IsTime()
+		b.dates[ifmt] = date
+	}
+	return date
+}
+
+// numberCell 日期格式的数值转为时间，文本按是否含日期、时间部分输出
+func (b *xlsBook) numberCell(xf int, v float64) Cell {
+	if v < 0 || !b.isDate(xf) {
+		return numberCell(v)
+	}
+	t := xlsx.TimeFromExcelTime(v, b.date1904)
+	layout := "2006-01-02 15:04:05"
+	switch {
+	case v < 1:
+		layout = "15:04:05"
+	case v == math.Trunc(v):
+		layout = "2006-01-02"
+	}
+	return Cell{Type: CellDate, Text: t.Format(layout), Time: &t}
+}
+
+func stringCell(v string) Cell {
+	return Cell{Type: CellString, Text: v}
+}
+
+func numberCell(v float64) Cell {
+	return Cell{Type: CellNumber, Text: strconv.FormatFloat(v, 'f', -1, 64), Number: v}
+}
+
+func boolCell(v bool) Cell {
+	return Cell{Type: CellBool, Text: strconv.FormatBool(v), Bool: v}
+}
+
+// readBiffShortString 读取 1 字节长度的 ShortXLUnicodeString
+func readBiffShortString(b []byte) (string, int) {
+	if len(b) < 2 {
+		return "", len(b)
+	}
+	return readBiffChars(b[2:], int(b[0]), b[1]&0x01 != 0)
+}
+
+// readBiffString 读取 2 字节长度的 XLUnicodeString
+func readBiffString(b []byte) (string, int) {
+	if len(b) < 3 {
+		return "", len(b)
+	}
+	return readBiffChars(b[3:], int(binary.LittleEndian.Uint16(b)), b[2]&0x01 != 0)
+}
+
+func readBiffChars(b []byte, n int, high bool) (string, int) {
+	if high {
+		if n*2 > len(b) {
+			n = len(b) / 2
+		}
+		return decodeUtf16(b[:n*2]), n * 2
+	}
+	if n > len(b) {
+		n = len(b)
+	}
+	return decodeCp1252(b[:n]), n
+}
+
+// sstReader 跨 CONTINUE 记录读取共享字符串表
+type sstReader struct {
+	segs [][]byte
+	seg  int
+	pos  int
+}
+
+func (r *sstReader) eof() bool {
+	for r.seg < len(r.segs) && r.pos >= len(r.segs[r.seg]) {
+		r.seg++
+		r.pos = 0
+	}
+	return r.seg >= len(r.segs)
+}
+
+// bytes 读取 n 个字节，可跨越记录边界
+func (r *sstReader) bytes(n int) []byte {
+	var out []byte
+	for n > 0 && !r.eof() {
+		cur := r.segs[r.seg][r.pos:]
+		k := n
+		if k > len(cur) {
+			k = len(cur)
+		}
+		out = append(out, cur[:k]...)
+		r.pos += k
+		n -= k
+	}
+	return out
+}
+
+// chars 读取 n 个字符，字符跨越记录边界时新记录首字节为压缩标志
+func (r *sstReader) chars(n int, high bool) string {
+	var runes []rune
+	for n > 0 && r.seg < len(r.segs) {
+		cur := r.segs[r.seg][r.pos:]
+		width := 1
+		if high {
+			width = 2
+		}
+		k := n
+		if k*width > len(cur) {
+			k = len(cur) / width
+		}
+		if high {
+			runes = append(runes, []rune(decodeUtf16(cur[:k*2]))...)
+		} else {
+			runes = append(runes, []rune(decodeCp1252(cur[:k]))...)
+		}
+		r.pos += k * width
+		n -= k
+		if n == 0 {
+			break
+		}
+		r.seg++
+		r.pos = 0
+		if r.seg >= len(r.segs) || len(r.segs[r.seg]) == 0 {
+			break
+		}
+		high = r.segs[r.seg][0]&0x01 != 0
+		r.pos = 1
+	}
+	return string(runes)
+}
+
+func parseSst(rec biffRecord) []string {
+	if len(rec.data) < 8 {
+		return nil
+	}
+	unique := int(binary.LittleEndian.Uint32(rec.data[4:]))
+	r := &sstReader{segs: append([][]byte{rec.data[8:]}, rec.continues...)}
+	// unique 来自文件，按实际字节数限制容量：每个字符串至少占 3 字节
+	n := len(rec.data) - 8
+	for _, c := range rec.continues {
+		n += len(c)
+	}
+	if unique > n/3 {
+		unique = n / 3
+	}
+	res := make([]string, 0, unique)
+	for i := 0; i < unique && !r.eof(); i++ {
+		head := r.bytes(3)
+		if len(head) < 3 {
+			break
+		}
+		cch := int(binary.LittleEndian.Uint16(head))
+		flags := head[2]
+		var runs, ext int
+		if flags&0x08 != 0 {
+			runs = int(binary.LittleEndian.Uint16(padBytes(r.bytes(2), 2)))
+		}
+		if flags&0x04 != 0 {
+			ext = int(int32(binary.LittleEndian.Uint32(padBytes(r.bytes(4), 4))))
+		}
+		res = append(res, r.chars(cch, flags&0x01 != 0))
+		r.bytes(runs * 4)
+		if ext > 0 {
+			r.bytes(ext)
+		}
+	}
+	return res
+}
+
+func padBytes(b []byte, n int) []byte {
+	for len(b) < n {
+		b = append(b, 0)
+	}
+	return b
+}
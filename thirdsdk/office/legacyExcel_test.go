@@ -0,0 +1,78 @@
+package office
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExcelToContentXls(t *testing.T) {
+	sheets, suffix, _, err := ExcelToContentTwo("testdata/legacy.xls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if suffix != "xls" {
+		t.Errorf("suffix = %s", suffix)
+	}
+	want := []ExcelResult{
+		{Name: "Sheet一", Content: [][]string{
+			{"问题", "答案"},
+			{"你好吗", "我很好长长长长长长长长长长"},
+			{"3.5", "12"},
+			{"7", "12.34"},
+			{"label", "ab"},
+			{"true"},
+			{"2024-01-02", "2024-01-02 12:00:00"},
+			{"15.5", "8", "x"},
+		}},
+		{Name: "S2", Content: [][]string{{"", "", "x"}}},
+	}
+	if !reflect.DeepEqual(sheets, want) {
+		t.Errorf("sheets = %q", sheets)
+	}
+}
+
+func TestExcelToSheetsXls(t *testing.T) {
+	sheets, _, _, err := ExcelToSheets("testdata/legacy.xls", ExcelOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cells := sheets[0].Cells
+	date := cells[6][0]
+	if date.Type != CellDate || date.Time == nil || !date.Time.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("date cell = %+v", date)
+	}
+	if dt := cells[6][1]; dt.Type != CellDate || dt.Text != "2024-01-02 12:00:00" {
+		t.Errorf("datetime cell = %+v", dt)
+	}
+	var formulas []string
+	for _, c := range cells[7] {
+		formulas = append(formulas, c.Formula)
+	}
+	if want := []string{"=SUM(A3:B3)", "=A3*2+1", "=S2!C1"}; !reflect.DeepEqual(formulas, want) {
+		t.Errorf("formulas = %q", formulas)
+	}
+}
+
+func TestXlsGridSparse(t *testing.T) {
+	s := &xlsSheet{cells: make(map[int]map[int]Cell), maxRow: -1, maxCol: -1}
+	s.set(0, 0, stringCell("a"))
+	s.set(65535, 255, stringCell("z"))
+	s.merged = []CellRange{{FirstRow: 0, FirstCol: 0, LastRow: 65535, LastCol: 255}}
+	sheet := s.grid().sheet(ExcelOptions{})
+	if len(sheet.Cells) != 65536 || len(sheet.Cells[1]) != 0 || len(sheet.Cells[0]) != 256 {
+		t.Errorf("rows = %d, row 1 = %d, row 0 = %d", len(sheet.Cells), len(sheet.Cells[1]), len(sheet.Cells[0]))
+	}
+}
+
+func TestParseSstBoundsCount(t *testing.T) {
+	data := make([]byte, 8, 14)
+	binary.LittleEndian.PutUint32(data[4:], 0xFFFFFFFF)
+	// 一个 1 字符的压缩字符串
+	data = append(data, 1, 0, 0, 'a')
+	got := parseSst(biffRecord{data: data})
+	if !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("parseSst = %q", got)
+	}
+}
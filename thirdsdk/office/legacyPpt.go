@@ -0,0 +1,235 @@
+package office
+
+import (
+	"encoding/binary"
+	"io"
+	"strings"
+
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
+	"github.com/comqositi/toolkits/thirdsdk/internal/ole2"
+)
+
+// PowerPoint 97-2003 二进制格式（.ppt）。文件可能经过多次增量保存，流中残留已删除或旧版本的幻灯片，
+// 因此从 Current User 指向的最新 UserEditAtom 出发，沿编辑链合并 PersistDirectory，
+// 再按文档中幻灯片列表的顺序取出每张幻灯片
+
+const (
+	pptDocumentContainer = 0x03E8
+	pptSlide             = 0x03EE
+	pptSlidePersistAtom  = 0x03F3
+	pptSlideListText     = 0x0FF0
+	pptTextCharsAtom     = 0x0FA0
+	pptTextBytesAtom     = 0x0FA8
+	pptUserEditAtom      = 0x0FF5
+	pptCurrentUserAtom   = 0x0FF6
+	pptPersistDirectory  = 0x1772
+	pptMaxDepth          = 32
+)
+
+var errPptFormat = errorx.New(errorx.ErrParse, "invalid ppt edit chain")
+
+type pptFile struct {
+	stream []byte
+	// persist 编号到记录在流中的偏移，较新的编辑覆盖旧的
+	persist map[uint32]uint32
+}
+
+// pptSlideRef SlideListWithText 中的一张幻灯片
+type pptSlideRef struct {
+	persistId uint32
+	// 占位符文本
+	texts []string
+}
+
+func readPptBinary(r io.ReaderAt, size int64) ([][]string, error) {
+	ole, err := ole2.Open(r, size)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := ole.Stream("PowerPoint Document")
+	if err != nil {
+		return nil, err
+	}
+	user, err := ole.Stream("Current User")
+	if err != nil {
+		return nil, err
+	}
+
+	p := &pptFile{stream: stream, persist: make(map[uint32]uint32)}
+	docRef, err := p.readEdits(user)
+	if err != nil {
+		return nil, err
+	}
+	docOff, ok := p.persist[docRef]
+	if !ok {
+		return nil, errPptFormat
+	}
+	typ, _, doc, ok := pptRecord(stream, docOff)
+	if !ok || typ != pptDocumentContainer {
+		return nil, errPptFormat
+	}
+
+	refs := pptSlideList(doc)
+	slides := make([][]string, len(refs))
+	for i, ref := range refs {
+		texts := ref.texts
+		if off, ok := p.persist[ref.persistId]; ok {
+			if typ, _, body, ok := pptRecord(stream, off); ok && typ == pptSlide {
+				texts = pptTexts(body, texts, 0)
+			}
+		}
+		slides[i] = dedupTexts(texts)
+	}
+	return slides, nil
+}
+
+// readEdits 沿 UserEditAtom 链从新到旧读取 PersistDirectory，返回最新的文档 persist 编号
+func (p *pptFile) readEdits(user []byte) (uint32, error) {
+	typ, _, body, ok := pptRecord(user, 0)
+	if !ok || typ != pptCurrentUserAtom || len(body) < 12 {
+		return 0, errPptFormat
+	}
+	off := binary.LittleEndian.Uint32(body[8:])
+
+	var docRef uint32
+	visited := make(map[uint32]bool)
+	for first := true; ; first = false {
+		if visited[off] {
+			return 0, errPptFormat
+		}
+		visited[off] = true
+		typ, _, edit, ok := pptRecord(p.stream, off)
+		if !ok || typ != pptUserEditAtom || len(edit) < 20 {
+			return 0, errPptFormat
+		}
+		if first {
+			docRef = binary.LittleEndian.Uint32(edit[16:])
+		}
+		if err := p.readPersistDirectory(binary.LittleEndian.Uint32(edit[12:])); err != nil {
+			return 0, err
+		}
+		// offsetLastEdit 为 0 表示最早的一次保存
+		if off = binary.LittleEndian.Uint32(edit[8:]); off == 0 {
+			return docRef, nil
+		}
+	}
+}
+
+// readPersistDirectory 每项以 persistId（低 20 位）和数量（高 12 位）开头，后跟连续编号的偏移
+func (p *pptFile) readPersistDirectory(off uint32) error {
+	typ, _, body, ok := pptRecord(p.stream, off)
+	if !ok || typ != pptPersistDirectory {
+		return errPptFormat
+	}
+	for i := 0; i+4 <= len(body); {
+		head := binary.LittleEndian.Uint32(body[i:])
+		id, n := head&0xFFFFF, int(head>>20)
+		i += 4
+		if i+n*4 > len(body) {
+			return errPptFormat
+		}
+		for j := 0; j < n; j++ {
+			if _, ok := p.persist[id+uint32(j)]; !ok {
+				p.persist[id+uint32(j)] = binary.LittleEndian.Uint32(body[i+j*4:])
+			}
+		}
+		i += n * 4
+	}
+	return nil
+}
+
+// pptSlideList 读取文档中 instance 为 0 的 SlideListWithText，母版（含标题母版）和备注在其他 instance 中
+func pptSlideList(doc []byte) []pptSlideRef {
+	var refs []pptSlideRef
+	pptEach(doc, func(typ, inst uint16, _ bool, body []byte) {
+		if typ != pptSlideListText || inst != 0 {
+			return
+		}
+		pptEach(body, func(typ, _ uint16, _ bool, body []byte) {
+			switch typ {
+			case pptSlidePersistAtom:
+				if len(body) >= 4 {
+					refs = append(refs, pptSlideRef{persistId: binary.LittleEndian.Uint32(body)})
+				}
+			case pptTextCharsAtom, pptTextBytesAtom:
+				if len(refs) > 0 {
+					last := &refs[len(refs)-1]
+					last.texts = appendPptText(last.texts, typ, body)
+				}
+			}
+		})
+	})
+	return refs
+}
+
+// pptTexts 收集幻灯片容器中绘图对象的文本，depth 限制嵌套层数
+func pptTexts(data []byte, texts []string, depth int) []string {
+	if depth > pptMaxDepth {
+		return texts
+	}
+	pptEach(data, func(typ, _ uint16, container bool, body []byte) {
+		switch {
+		case typ == pptTextCharsAtom, typ == pptTextBytesAtom:
+			texts = appendPptText(texts, typ, body)
+		case container:
+			texts = pptTexts(body, texts, depth+1)
+		}
+	})
+	return texts
+}
+
+func appendPptText(texts []string, typ uint16, body []byte) []string {
+	var text string
+	if typ == pptTextCharsAtom {
+		text = decodeUtf16(body)
+	} else {
+		text = decodeCp1252(body)
+	}
+	// 段落以 \r 分隔，段内换行为垂直制表符
+	text = strings.NewReplacer("\r", "\n", "\x0b", "\n").Replace(text)
+	if text = strings.TrimSpace(text); text != "" {
+		texts = append(texts, text)
+	}
+	return texts
+}
+
+// dedupTexts 去掉占位符和绘图对象中重复的文本
+func dedupTexts(texts []string) []string {
+	seen := make(map[string]bool)
+	var res []string
+	for _, t := range texts {
+		if !seen[t] {
+			seen[t] = true
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+// pptEach 依次处理 data 中的记录，recVer 为 0xF 的是容器记录；遇到越界的记录时停止
+func pptEach(data []byte, fn func(typ, inst uint16, container bool, body []byte)) {
+	for off := 0; off+8 <= len(data); {
+		verInst := binary.LittleEndian.Uint16(data[off:])
+		typ := binary.LittleEndian.Uint16(data[off+2:])
+		n := int64(binary.LittleEndian.Uint32(data[off+4:]))
+		if int64(off)+8+n > int64(len(data)) {
+			return
+		}
+		body := data[off+8 : off+8+int(n)]
+		off += 8 + int(n)
+		fn(typ, verInst>>4, verInst&0x000F == 0x000F, body)
+	}
+}
+
+// pptRecord 读取 off 处的记录
+func pptRecord(data []byte, off uint32) (typ, inst uint16, body []byte, ok bool) {
+	if int64(off)+8 > int64(len(data)) {
+		return 0, 0, nil, false
+	}
+	head := data[off:]
+	n := int64(binary.LittleEndian.Uint32(head[4:]))
+	if 8+n > int64(len(head)) {
+		return 0, 0, nil, false
+	}
+	return binary.LittleEndian.Uint16(head[2:]), binary.LittleEndian.Uint16(head) >> 4, head[8 : 8+n], true
+}
@@ -0,0 +1,48 @@
+package office
+
+import (
+	"reflect"
+	"testing"
+)
+
+// legacy.ppt 保存过两次：第二次修改了第一张幻灯片、删除了第二张并新增一张，文件中还有标题母版
+func TestPptToSlidesPpt(t *testing.T) {
+	slides, suffix, _, err := PptToSlides("testdata/legacy.ppt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if suffix != "ppt" {
+		t.Errorf("suffix = %s", suffix)
+	}
+	want := [][]string{
+		{"标题一", "正文\n第二段", "Extra box"},
+		{"Slide two"},
+	}
+	var got [][]string
+	for i, s := range slides {
+		if s.Index != i+1 {
+			t.Errorf("slide %d index = %d", i, s.Index)
+		}
+		var texts []string
+		for _, b := range s.Blocks {
+			texts = append(texts, b.Text)
+		}
+		got = append(got, texts)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("slides = %q, want %q", got, want)
+	}
+}
+
+func TestPptTextsDepthLimit(t *testing.T) {
+	data := []byte{0x00, 0x00, 0xA8, 0x0F, 1, 0, 0, 0, 'x'}
+	for i := 0; i < pptMaxDepth+8; i++ {
+		head := []byte{0x0F, 0x00, 0x00, 0xF0, 0, 0, 0, 0}
+		head[4] = byte(len(data))
+		head[5] = byte(len(data) >> 8)
+		data = append(head, data...)
+	}
+	if texts := pptTexts(data, nil, 0); len(texts) != 0 {
+		t.Errorf("texts = %q", texts)
+	}
+}
@@ -0,0 +1,182 @@
+package office
+
+import (
+	"encoding/binary"
+	"io"
+	"strings"
+
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
+	"github.com/comqositi/toolkits/thirdsdk/internal/ole2"
+)
+
+// Word 97-2003 二进制格式（.doc），通过 FIB 中的 CLX 片段表还原正文文本
+
+const (
+	fibIdent        = 0xA5EC
+	fibFlagEncrypt  = 0x0100
+	fibFlagTableOne = 0x0200
+	fibCcpText      = 0x004C
+	fibFcClx        = 0x01A2
+	fibLcbClx       = 0x01A6
+	pcdCompressed   = 0x40000000
+)
+
+var errDocPieces = errorx.New(errorx.ErrParse, "corrupt word piece table")
+
+func readDocBinary(r io.ReaderAt, size int64) (string, error) {
+	ole, err := ole2.Open(r, size)
+	if err != nil {
+		return "", err
+	}
+	wordStream, err := ole.Stream("WordDocument")
+	if err != nil {
+		return "", err
+	}
+	if len(wordStream) < fibLcbClx+4 || binary.LittleEndian.Uint16(wordStream) != fibIdent {
+		return "", errorx.New(errorx.ErrUnsupportedFormat, "not a word document")
+	}
+
+	flags := binary.LittleEndian.Uint16(wordStream[0x0A:])
+	if flags&fibFlagEncrypt != 0 {
+		return "", errorx.ErrEncrypted
+	}
+	tableName := "0Table"
+	if flags&fibFlagTableOne != 0 {
+		tableName = "1Table"
+	}
+	tableStream, err := ole.Stream(tableName)
+	if err != nil {
+		return "", err
+	}
+
+	ccpText := int(binary.LittleEndian.Uint32(wordStream[fibCcpText:]))
+	fcClx := int(binary.LittleEndian.Uint32(wordStream[fibFcClx:]))
+	lcbClx := int(binary.LittleEndian.Uint32(wordStream[fibLcbClx:]))
+	if lcbClx <= 0 || fcClx+lcbClx > len(tableStream) {
+		return "", errDocPieces
+	}
+
+	text, err := readDocPieces(wordStream, tableStream[fcClx:fcClx+lcbClx], ccpText)
+	if err != nil {
+		return "", err
+	}
+	return cleanDocText(text), nil
+}
+
+// readDocPieces 按片段表拼接正文的前 ccpText 个字符
+func readDocPieces(wordStream []byte, clx []byte, ccpText int) ([]rune, error) {
+	// 跳过 Prc（格式修改），找到 Pcdt
+	i := 0
+	for i < len(clx) && clx[i] == 0x01 {
+		if i+3 > len(clx) {
+			return nil, errDocPieces
+		}
+		i += 3 + int(binary.LittleEndian.Uint16(clx[i+1:]))
+	}
+	if i+5 > len(clx) || clx[i] != 0x02 {
+		return nil, errDocPieces
+	}
+	lcb := int(binary.LittleEndian.Uint32(clx[i+1:]))
+	plc := clx[i+5:]
+	if lcb > len(plc) || lcb < 4 {
+		return nil, errDocPieces
+	}
+	plc = plc[:lcb]
+
+	// PlcPcd: (n+1) 个 CP 后跟 n 个 8 字节的 PCD
+	n := (lcb - 4) / 12
+	var text []rune
+	for k := 0; k < n && len(text) < ccpText; k++ {
+		cpStart := int(binary.LittleEndian.Uint32(plc[k*4:]))
+		cpEnd := int(binary.LittleEndian.Uint32(plc[(k+1)*4:]))
+		pcd := plc[(n+1)*4+k*8:]
+		fc := binary.LittleEndian.Uint32(pcd[2:])
+		count := cpEnd - cpStart
+		if count <= 0 {
+			continue
+		}
+
+		if fc&pcdCompressed != 0 {
+			off := int(fc&^pcdCompressed) / 2
+			if off+count > len(wordStream) {
+				return nil, errDocPieces
+			}
+			text = append(text, []rune(decodeCp1252(wordStream[off:off+count]))...)
+		} else {
+			off := int(fc)
+			if off+count*2 > len(wordStream) {
+				return nil, errDocPieces
+			}
+			text = append(text, []rune(decodeUtf16(wordStream[off:off+count*2]))...)
+		}
+	}
+	if len(text) > ccpText {
+		text = text[:ccpText]
+	}
+	return text, nil
+}
+
+// cleanDocText 处理段落标记、单元格标记和域代码等控制字符
+func cleanDocText(text []rune) string {
+	var (
+		sb strings.Builder
+		// 域的嵌套栈，true 表示处于域代码部分（不输出）
+		fields []bool
+	)
+	for _, c := range text {
+		switch c {
+		case 0x13: // 域开始
+			fields = append(fields, true)
+			continue
+		case 0x14: // 域分隔符，之后为域结果
+			if len(fields) > 0 {
+				fields[len(fields)-1] = false
+			}
+			continue
+		case 0x15: // 域结束
+			if len(fields) > 0 {
+				fields = fields[:len(fields)-1]
+			}
+			continue
+		}
+		if inFieldCode(fields) {
+			continue
+		}
+
+		switch {
+		case c == '\r', c == 0x0B, c == 0x0C:
+			sb.WriteRune('\n')
+		case c == 0x07:
+			sb.WriteRune('\t')
+		case c == 0x1E:
+			sb.WriteRune('-')
+		case c == 0xA0:
+			sb.WriteRune(' ')
+		case c == '\t':
+			sb.WriteRune(c)
+		case c < 0x20:
+			// 图片、脚注引用等占位符
+		default:
+			sb.WriteRune(c)
+		}
+	}
+
+	lines := strings.Split(sb.String(), "\n")
+	res := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.TrimRight(line, "\t ")
+		if strings.TrimSpace(line) != "" {
+			res = append(res, line)
+		}
+	}
+	return strings.Join(res, "\n")
+}
+
+func inFieldCode(fields []bool) bool {
+	for _, code := range fields {
+		if code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package office
+
+import (
+	"os"
+	"testing"
+)
+
+func TestWordToContentDoc(t *testing.T) {
+	text, suffix, size, err := WordToContent("testdata/legacy.doc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if suffix != "doc" || size != 8704 {
+		t.Errorf("suffix, size = %s, %d", suffix, size)
+	}
+	want := "Hello “world”\n中文段落链接结束\na\tb\nEnd"
+	if text != want {
+		t.Errorf("text = %q, want %q", text, want)
+	}
+}
+
+func TestOpenOleRejectsBadHeader(t *testing.T) {
+	data, err := os.ReadFile("testdata/legacy.doc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		off  int
+		val  []byte
+	}{
+		{"mini cutoff", 0x38, []byte{0xFF, 0xFF, 0xFF, 0x7F}},
+		{"byte order", 0x1C, []byte{0xFF, 0xFF}},
+		{"version", 0x1A, []byte{4, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := append([]byte(nil), data...)
+			copy(b[tt.off:], tt.val)
+			if _, _, _, err := WordFromBytes(b); err == nil {
+				t.Error("want error")
+			}
+		})
+	}
+}
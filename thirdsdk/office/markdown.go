@@ -0,0 +1,315 @@
+package office
+
+import (
+	"context"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
+	"github.com/comqositi/toolkits/thirdsdk/filetype"
+)
+
+// WordToMarkdown word文件转 Markdown
+func WordToMarkdown(filePath string) (word string, fileSuffix string, FileSize int, err error) {
+	t, err := detectType(filePath, filePath)
+	if err != nil {
+		return "", "", 0, errorx.Wrap(errorx.ErrUnsupportedFormat, err)
+	}
+	size, err := countSize(filePath)
+	if err != nil {
+		return "", "", 0, errorx.Wrap(errorx.ErrRead, err)
+	}
+
+	if t == filetype.Doc {
+		text, err := wordToData(filePath, t, WordOptions{})
+		if err != nil {
+			return "", "", 0, err
+		}
+		return textToMarkdown(text), t.Ext, size, nil
+	}
+
+	doc, err := readWordDocument(filePath, WordOptions{})
+	if err != nil {
+		return "", "", 0, err
+	}
+	return doc.Markdown(), t.Ext, size, nil
+}
+
+// WordUrlToMarkdown word地址文件转 Markdown
+func WordUrlToMarkdown(url string) (word string, fileSuffix string, FileSize int, err error) {
+	return WordUrlToMarkdownCtx(context.Background(), url)
+}
+
+// WordUrlToMarkdownCtx 同 WordUrlToMarkdown，ctx 取消时中止请求
+func WordUrlToMarkdownCtx(ctx context.Context, url string) (word string, fileSuffix string, FileSize int, err error) {
+	return withUrlFile(ctx, url, WordToMarkdown)
+}
+
+// PptToMarkdown ppt文件转 Markdown，每张幻灯片以标题作为二级标题
+func PptToMarkdown(filePath string) (word string, fileSuffix string, FileSize int, err error) {
+	t, err := detectType(filePath, filePath)
+	if err != nil {
+		return "", "", 0, errorx.Wrap(errorx.ErrUnsupportedFormat, err)
+	}
+	size, err := countSize(filePath)
+	if err != nil {
+		return "", "", 0, errorx.Wrap(errorx.ErrRead, err)
+	}
+
+	var slides []Slide
+	err = readLocalFile(filePath, func(r io.ReaderAt, size int64) (err error) {
+		slides, err = parseSlides(r, size, t)
+		return err
+	})
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	var parts []string
+	for _, slide := range slides {
+		parts = append(parts, slide.markdown())
+	}
+	return strings.Join(parts, "\n\n"), t.Ext, size, nil
+}
+
+// PptUrlToMarkdown ppt地址文件转 Markdown
+func PptUrlToMarkdown(url string) (word string, fileSuffix string, FileSize int, err error) {
+	return PptUrlToMarkdownCtx(context.Background(), url)
+}
+
+// PptUrlToMarkdownCtx 同 PptUrlToMarkdown，ctx 取消时中止请求
+func PptUrlToMarkdownCtx(ctx context.Context, url string) (word string, fileSuffix string, FileSize int, err error) {
+	return withUrlFile(ctx, url, PptToMarkdown)
+}
+
+// ExcelToMarkdown excel文件转 Markdown，每个工作表一个表格，首行作为表头
+func ExcelToMarkdown(filePath string) (word string, fileSuffix string, FileSize int, err error) {
+	sheets, suffix, size, err := ExcelToContentTwo(filePath)
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	var parts []string
+	for _, sheet := range sheets {
+		part := "## " + sheet.Name
+		if table := markdownTable(sheet.Content); table != "" {
+			part += "\n\n" + table
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "\n\n"), suffix, size, nil
+}
+
+// ExcelUrlToMarkdown excel地址文件转 Markdown
+func ExcelUrlToMarkdown(url string) (word string, fileSuffix string, FileSize int, err error) {
+	return ExcelUrlToMarkdownCtx(context.Background(), url)
+}
+
+// ExcelUrlToMarkdownCtx 同 ExcelUrlToMarkdown，ctx 取消时中止请求
+func ExcelUrlToMarkdownCtx(ctx context.Context, url string) (word string, fileSuffix string, FileSize int, err error) {
+	return withUrlFile(ctx, url, ExcelToMarkdown)
+}
+
+// PdfToMarkdown pdf文件转 Markdown，页与页之间以分隔线隔开
+func PdfToMarkdown(filePath string) (word string, fileSuffix string, FileSize int, err error) {
+	suffix, err := detectSuffix(filePath, filePath)
+	if err != nil {
+		return "", "", 0, errorx.Wrap(errorx.ErrUnsupportedFormat, err)
+	}
+	size, err := countSize(filePath)
+	if err != nil {
+		return "", "", 0, errorx.Wrap(errorx.ErrRead, err)
+	}
+
+	pages, err := readPdfPages(filePath, PdfOptions{Layout: true})
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	var parts []string
+	for _, page := range pages {
+		if md := textToMarkdown(page.Text); md != "" {
+			parts = append(parts, md)
+		}
+	}
+	return strings.Join(parts, "\n\n---\n\n"), suffix, size, nil
+}
+
+// PdfUrlToMarkdown pdf url文件转 Markdown
+func PdfUrlToMarkdown(url string) (word string, fileSuffix string, FileSize int, err error) {
+	return PdfUrlToMarkdownCtx(context.Background(), url)
+}
+
+// PdfUrlToMarkdownCtx 同 PdfUrlToMarkdown，ctx 取消时中止请求
+func PdfUrlToMarkdownCtx(ctx context.Context, url string) (word string, fileSuffix string, FileSize int, err error) {
+	return withUrlFile(ctx, url, PdfToMarkdown)
+}
+
+// Markdown 标题输出为 #，列表输出为 - 或 1.，表格输出为 GFM 表格
+func (d *WordDocument) Markdown() string {
+	var sb strings.Builder
+	for i, b := range d.Blocks {
+		if i > 0 {
+			// 连续的列表项之间不空行
+			if b.Type == BlockListItem && d.Blocks[i-1].Type == BlockListItem {
+				sb.WriteString("\n")
+			} else {
+				sb.WriteString("\n\n")
+			}
+		}
+		switch b.Type {
+		case BlockHeading:
+			level := b.Level
+			if level > 6 {
+				level = 6
+			}
+			sb.WriteString(strings.Repeat("#", level) + " " + markdownInline(b.Text))
+		case BlockListItem:
+			sb.WriteString(markdownListItem(b.Text, b.Level, b.Ordered))
+		case BlockTable:
+			sb.WriteString(markdownTable(b.Table.grid()))
+		default:
+			sb.WriteString(markdownInline(b.Text))
+		}
+	}
+	return sb.String()
+}
+
+// grid 将横向合并的单元格展开为多列，便于输出规整的表格
+func (t *Table) grid() [][]string {
+	rows := make([][]string, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		var cells []string
+		for _, cell := range row.Cells {
+			cells = append(cells, cell.Text)
+			for i := 1; i < cell.ColSpan; i++ {
+				cells = append(cells, "")
+			}
+		}
+		rows = append(rows, cells)
+	}
+	return rows
+}
+
+func (s *Slide) markdown() string {
+	title := s.Title
+	if title == "" {
+		title = "幻灯片 " + strconv.Itoa(s.Index)
+	}
+	parts := []string{"## " + markdownInline(title)}
+
+	var list []string
+	flush := func() {
+		if len(list) > 0 {
+			parts = append(parts, strings.Join(list, "\n"))
+			list = nil
+		}
+	}
+	for _, b := range s.Blocks {
+		switch b.Type {
+		case BlockListItem:
+			list = append(list, markdownListItem(b.Text, b.Level, b.Ordered))
+			continue
+		case BlockTable:
+			flush()
+			if table := markdownTable(b.Table.grid()); table != "" {
+				parts = append(parts, table)
+			}
+		default:
+			flush()
+			parts = append(parts, markdownInline(b.Text))
+		}
+	}
+	flush()
+
+	if s.Notes != "" {
+		var quote []string
+		for _, line := range strings.Split("备注："+s.Notes, "\n") {
+			quote = append(quote, "> "+markdownInline(line))
+		}
+		parts = append(parts, strings.Join(quote, "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// textToMarkdown 纯文本按非空行输出为段落
+func textToMarkdown(text string) string {
+	var paragraphs []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			paragraphs = append(paragraphs, markdownInline(line))
+		}
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
+
+func markdownListItem(text string, level int, ordered bool) string {
+	marker := "- "
+	if ordered {
+		marker = "1. "
+	}
+	indent := strings.Repeat("    ", level)
+	// 列表项内换行需要缩进才能保持在同一项中
+	text = strings.ReplaceAll(markdownInline(text), "\n", "\n"+indent+strings.Repeat(" ", len(marker)))
+	return indent + marker + text
+}
+
+// markdownInline 段内换行使用行尾两个空格表示硬换行
+func markdownInline(text string) string {
+	return strings.ReplaceAll(strings.TrimSpace(text), "\n", "  \n")
+}
+
+// markdownTable 输出 GFM 表格，首行作为表头，列数不足的行补空单元格
+func markdownTable(rows [][]string) string {
+	cols := 0
+	for _, row := range rows {
+		if len(row) > cols {
+			cols = len(row)
+		}
+	}
+	if cols == 0 {
+		return ""
+	}
+
+	line := func(row []string) string {
+		cells := make([]string, cols)
+		for i := range cells {
+			if i < len(row) {
+				cells[i] = markdownCell(row[i])
+			}
+		}
+		return "| " + strings.Join(cells, " | ") + " |"
+	}
+
+	lines := []string{line(rows[0])}
+	sep := make([]string, cols)
+	for i := range sep {
+		sep[i] = "---"
+	}
+	lines = append(lines, "| "+strings.Join(sep, " | ")+" |")
+	for _, row := range rows[1:] {
+		lines = append(lines, line(row))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func markdownCell(text string) string {
+	text = strings.TrimSpace(text)
+	text = strings.ReplaceAll(text, "|", "\\|")
+	text = strings.ReplaceAll(text, "\r\n", "<br>")
+	return strings.ReplaceAll(text, "\n", "<br>")
+}
+
+// withUrlFile 下载 url 文件到本地后交给 fn 处理
+func withUrlFile(ctx context.Context, url string, fn func(filePath string) (string, string, int, error)) (string, string, int, error) {
+	suffix, _ := getSuffix(url)
+	filePath, err := saveFile(ctx, url, suffix)
+	if err != nil {
+		return "", "", 0, errorx.Wrap(errorx.ErrDownload, err)
+	}
+	defer os.Remove(filePath)
+
+	return fn(filePath)
+}
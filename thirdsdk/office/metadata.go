@@ -0,0 +1,203 @@
+package office
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// coreProps docProps/core.xml 中的文档属性，按本地名匹配，忽略命名空间
+type coreProps struct {
+	Title    string `xml:"title"`
+	Creator  string `xml:"creator"`
+	Language string `xml:"language"`
+	Created  string `xml:"created"`
+	Modified string `xml:"modified"`
+}
+
+// appProps docProps/app.xml 中的应用属性
+type appProps struct {
+	Pages  int `xml:"Pages"`
+	Slides int `xml:"Slides"`
+}
+
+// readOoxmlMeta 读取 docx、xlsx、pptx 的文档属性写入 doc，返回 app.xml 中的属性；
+// 属性缺失不算错误，格式不正确时记入 doc.Warnings
+func readOoxmlMeta(r io.ReaderAt, size int64, doc *Document) appProps {
+	var app appProps
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return app
+	}
+	parts := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		parts[f.Name] = f
+	}
+
+	if f, ok := parts["docProps/core.xml"]; ok {
+		var core coreProps
+		if err := readZipXml(f, func(d *xml.Decoder) error { return d.Decode(&core) }); err != nil {
+			doc.Warnings = append(doc.Warnings, "invalid docProps/core.xml: "+err.Error())
+		}
+		doc.Title = strings.TrimSpace(core.Title)
+		doc.Author = strings.TrimSpace(core.Creator)
+		doc.Language = strings.TrimSpace(core.Language)
+		doc.Created = parseOoxmlDate(core.Created)
+		doc.Modified = parseOoxmlDate(core.Modified)
+	}
+	if f, ok := parts["docProps/app.xml"]; ok {
+		if err := readZipXml(f, func(d *xml.Decoder) error { return d.Decode(&app) }); err != nil {
+			doc.Warnings = append(doc.Warnings, "invalid docProps/app.xml: "+err.Error())
+		}
+	}
+	return app
+}
+
+// parseOoxmlDate 解析 W3CDTF 格式的时间，如 2024-01-02T03:04:05Z
+func parseOoxmlDate(s string) *time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+// readPdfInfo 读取 pdf 文件尾 Info 字典中的标题、作者和时间
+func readPdfInfo(r *pdf.Reader, doc *Document) {
+	info := r.Trailer().Key("Info")
+	if info.IsNull() {
+		return
+	}
+	doc.Title = strings.TrimSpace(info.Key("Title").Text())
+	doc.Author = strings.TrimSpace(info.Key("Author").Text())
+	doc.Created = parsePdfDate(info.Key("CreationDate").Text())
+	doc.Modified = parsePdfDate(info.Key("ModDate").Text())
+}
+
+// pdfDateLayouts 按数字位数对应的 pdf 时间格式，时分秒等可以省略
+var pdfDateLayouts = map[int]string{
+	4:  "2006",
+	6:  "200601",
+	8:  "20060102",
+	10: "2006010215",
+	12: "200601021504",
+	14: "20060102150405",
+}
+
+// parsePdfDate 解析 pdf 时间，如 D:20240102030405+08'00'
+func parsePdfDate(s string) *time.Time {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "D:")
+	digits := len(s) - len(strings.TrimLeft(s, "0123456789"))
+	layout, ok := pdfDateLayouts[digits]
+	if !ok {
+		return nil
+	}
+	loc := time.UTC
+	zone := strings.ReplaceAll(s[digits:], "'", "")
+	if len(zone) >= 3 && (zone[0] == '+' || zone[0] == '-') {
+		hour, _ := strconv.Atoi(zone[1:3])
+		minute := 0
+		if len(zone) >= 5 {
+			minute, _ = strconv.Atoi(zone[3:5])
+		}
+		offset := hour*3600 + minute*60
+		if zone[0] == '-' {
+			offset = -offset
+		}
+		loc = time.FixedZone("", offset)
+	}
+	t, err := time.ParseInLocation(layout, s[:digits], loc)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// 判断语言时最多取样的字符数
+const languageSample = 4000
+
+// stopWords 拉丁字母文本按常用虚词判断语言
+var stopWords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "for", "with"},
+	"fr": {"le", "la", "les", "et", "des", "est", "une", "pour", "dans"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "mit", "den", "ein"},
+	"es": {"el", "los", "las", "y", "que", "es", "una", "por", "para"},
+}
+
+// guessLanguage 按文字的书写系统粗略判断语言，返回 zh、ja、ko、ru、ar、en 等代码，无法判断时返回空串
+func guessLanguage(text string) string {
+	var han, kana, hangul, cyrillic, arabic, latin, n int
+	for _, r := range text {
+		if n >= languageSample {
+			break
+		}
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Arabic, r):
+			arabic++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		default:
+			continue
+		}
+		n++
+	}
+	if n == 0 {
+		return ""
+	}
+	// 日文夹杂汉字，假名占一定比例即认为是日文
+	if kana*10 >= n {
+		return "ja"
+	}
+	// 其余取字符最多的书写系统，一个汉字约等于一个单词，字母按 4 个折算一个
+	scripts := []struct {
+		lang  string
+		count int
+	}{{"", latin / 4}, {"zh", han}, {"ko", hangul}, {"ru", cyrillic}, {"ar", arabic}}
+	best := scripts[0]
+	for _, s := range scripts[1:] {
+		if s.count > best.count {
+			best = s
+		}
+	}
+	if best.lang == "" {
+		return guessLatinLanguage(text)
+	}
+	return best.lang
+}
+
+func guessLatinLanguage(text string) string {
+	if len(text) > languageSample*4 {
+		text = text[:languageSample*4]
+	}
+	counts := make(map[string]int)
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		counts[w]++
+	}
+	best, bestScore := "", 0
+	for _, lang := range []string{"en", "fr", "de", "es"} {
+		score := 0
+		for _, w := range stopWords[lang] {
+			score += counts[w]
+		}
+		if score > bestScore {
+			best, bestScore = lang, score
+		}
+	}
+	return best
+}
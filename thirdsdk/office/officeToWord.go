@@ -1,16 +1,14 @@
 package office
 
 import (
-	"baliance.com/gooxml/presentation"
 	"bytes"
-	"errors"
+	"context"
+	"github.com/comqositi/toolkits/thirdsdk/charset"
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
 	"github.com/ledongthuc/pdf"
-	"github.com/tealeg/xlsx"
-	"io/ioutil"
-	"log"
+	"io"
 	"os"
 	"strings"
-	"unicode/utf8"
 )
 
 type excelRes struct {
@@ -25,13 +23,13 @@ type ExcelResult struct {
 
 // word文件转文字
 func WordToContent(filePath string) (word string, fileSuffix string, FileSize int, err error) {
-	suffix, err := getSuffix(filePath)
+	t, err := detectType(filePath, filePath)
 	if err != nil {
-		return "", "", 0, errors.New("获取前缀失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrUnsupportedFormat, err)
 	}
 	size, err := countSize(filePath)
 	if err != nil {
-		return "", "", 0, errors.New("计算文件大小失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrRead, err)
 	}
 
 	//doc, err := document.Open(filePath)
@@ -48,204 +46,193 @@ func WordToContent(filePath string) (word string, fileSuffix string, FileSize in
 	//		text += r.Text()
 	//	}
 	//}
-	text, err := wordToData(filePath)
+	text, err := wordToData(filePath, t, WordOptions{})
 	if err != nil {
 		return "", "", 0, err
 	}
 
-	return text, suffix, size, nil
+	return text, t.Ext, size, nil
+}
+
+// WordToContentWithOptions 同 WordToContent，按 opts 输出修订并追加页眉、页脚、脚注、尾注和批注；doc 文件忽略 opts
+func WordToContentWithOptions(filePath string, opts WordOptions) (word string, fileSuffix string, FileSize int, err error) {
+	t, err := detectType(filePath, filePath)
+	if err != nil {
+		return "", "", 0, errorx.Wrap(errorx.ErrUnsupportedFormat, err)
+	}
+	size, err := countSize(filePath)
+	if err != nil {
+		return "", "", 0, errorx.Wrap(errorx.ErrRead, err)
+	}
+
+	text, err := wordToData(filePath, t, opts)
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	return text, t.Ext, size, nil
 }
 
 // word地址文件转文字
 func WordUrlToContent(url string) (word string, fileSuffix string, FileSize int, err error) {
-	suffix, err := getSuffix(url)
+	return WordUrlToContentCtx(context.Background(), url)
+}
+
+// WordUrlToContentCtx 同 WordUrlToContent，ctx 取消时中止请求
+func WordUrlToContentCtx(ctx context.Context, url string) (word string, fileSuffix string, FileSize int, err error) {
+	suffix, _ := getSuffix(url)
+	filePath, err := saveFile(ctx, url, suffix)
 	if err != nil {
-		return "", "", 0, errors.New("获取前缀失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrDownload, err)
 	}
+	defer os.Remove(filePath)
 
-	filePath, err := saveFile(url, suffix)
+	t, err := detectType(filePath, url)
 	if err != nil {
-		return "", "", 0, errors.New("文件保存在本地失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrUnsupportedFormat, err)
 	}
 
 	size, err := countSize(filePath)
 	if err != nil {
-		return "", "", 0, errors.New("计算文件大小失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrRead, err)
 	}
 
-	defer os.Remove(filePath)
-
-	text, err := wordToData(filePath)
+	text, err := wordToData(filePath, t, WordOptions{})
 	if err != nil {
 		return "", "", 0, err
 	}
 
-	return text, suffix, size, nil
+	return text, t.Ext, size, nil
+}
+
+// WordUrlToContentWithOptions 同 WordToContentWithOptions，读取 url 文件，ctx 取消时中止请求
+func WordUrlToContentWithOptions(ctx context.Context, url string, opts WordOptions) (word string, fileSuffix string, FileSize int, err error) {
+	return withUrlFile(ctx, url, func(filePath string) (string, string, int, error) {
+		return WordToContentWithOptions(filePath, opts)
+	})
 }
 
 // excel文件转文字
 func ExcelToContent(filePath string) (word []excelRes, fileSuffix string, FileSize int, err error) {
 	var list []excelRes
-	suffix, err := getSuffix(filePath)
+	t, err := detectType(filePath, filePath)
 	if err != nil {
-		return list, "", 0, errors.New("获取前缀失败！")
+		return list, "", 0, errorx.Wrap(errorx.ErrUnsupportedFormat, err)
 	}
 	size, err := countSize(filePath)
 	if err != nil {
-		return list, "", 0, errors.New("计算文件大小失败！")
+		return list, "", 0, errorx.Wrap(errorx.ErrRead, err)
 	}
-	xlFile, err := xlsx.OpenFile(filePath)
+	sheets, err := readExcel(filePath, t)
 	if err != nil {
-		return list, "", 0, errors.New("打开文件失败！")
+		return list, "", 0, err
 	}
 
-	for _, sheet := range xlFile.Sheets {
-		for k, row := range sheet.Rows {
-			if k == 0 {
-				continue
-			}
-			per := excelRes{}
-			per.Question = row.Cells[0].String()
-			per.Answer = row.Cells[1].String()
-			list = append(list, per)
-		}
-	}
-	return list, suffix, size, nil
+	list = excelToQa(sheets)
+	return list, t.Ext, size, nil
 }
 
 // ExcelToContentTwo excel文件转文字（方法二）
 func ExcelToContentTwo(filePath string) (word []ExcelResult, fileSuffix string, FileSize int, err error) {
 	var excelResult []ExcelResult
-	suffix, err := getSuffix(filePath)
+	t, err := detectType(filePath, filePath)
 	if err != nil {
-		return excelResult, "", 0, errors.New("获取前缀失败！")
+		return excelResult, "", 0, errorx.Wrap(errorx.ErrUnsupportedFormat, err)
 	}
 	size, err := countSize(filePath)
 	if err != nil {
-		return excelResult, "", 0, errors.New("计算文件大小失败！")
+		return excelResult, "", 0, errorx.Wrap(errorx.ErrRead, err)
 	}
-	xlFile, err := xlsx.OpenFile(filePath)
+	excelResult, err = readExcel(filePath, t)
 	if err != nil {
-		return excelResult, "", 0, errors.New("打开文件失败！")
+		return excelResult, "", 0, err
 	}
 
-	for _, sheet := range xlFile.Sheets {
-		list := ExcelResult{}
-		var table [][]string
-		for _, row := range sheet.Rows {
-			var rows []string
-			for _, cell := range row.Cells {
-				rows = append(rows, cell.String())
-			}
-			table = append(table, rows)
-		}
-
-		list.Content = table
-		list.Name = sheet.Name
-		excelResult = append(excelResult, list)
-	}
-	return excelResult, suffix, size, nil
+	return excelResult, t.Ext, size, nil
 }
 
 // excel地址文件转文字
 func ExcelUrlToContent(url string) (word []excelRes, fileSuffix string, FileSize int, err error) {
+	return ExcelUrlToContentCtx(context.Background(), url)
+}
+
+// ExcelUrlToContentCtx 同 ExcelUrlToContent，ctx 取消时中止请求
+func ExcelUrlToContentCtx(ctx context.Context, url string) (word []excelRes, fileSuffix string, FileSize int, err error) {
 	var list []excelRes
-	suffix, err := getSuffix(url)
+	suffix, _ := getSuffix(url)
+	filePath, err := saveFile(ctx, url, suffix)
 	if err != nil {
-		return list, "", 0, errors.New("获取前缀失败！")
+		return list, "", 0, errorx.Wrap(errorx.ErrDownload, err)
 	}
+	defer os.Remove(filePath)
 
-	filePath, err := saveFile(url, suffix)
+	t, err := detectType(filePath, url)
 	if err != nil {
-		return list, "", 0, errors.New("文件保存在本地失败！")
+		return list, "", 0, errorx.Wrap(errorx.ErrUnsupportedFormat, err)
 	}
 
 	size, err := countSize(filePath)
 	if err != nil {
-		return list, "", 0, errors.New("计算文件大小失败！")
+		return list, "", 0, errorx.Wrap(errorx.ErrRead, err)
 	}
 
-	xlFile, err := xlsx.OpenFile(filePath)
+	sheets, err := readExcel(filePath, t)
 	if err != nil {
-		return list, "", 0, errors.New("打开文件失败！")
+		return list, "", 0, err
 	}
 
-	defer os.Remove(filePath)
-
-	for _, sheet := range xlFile.Sheets {
-		for k, row := range sheet.Rows {
-			if k == 0 {
-				continue
-			}
-			per := excelRes{}
-			per.Question = row.Cells[0].String()
-			per.Answer = row.Cells[1].String()
-			list = append(list, per)
-		}
-	}
+	list = excelToQa(sheets)
 
-	return list, suffix, size, nil
+	return list, t.Ext, size, nil
 }
 
 // ExcelUrlToContentTwo  excel地址文件转文字（方法二）
 func ExcelUrlToContentTwo(url string) (word []ExcelResult, fileSuffix string, FileSize int, err error) {
+	return ExcelUrlToContentTwoCtx(context.Background(), url)
+}
+
+// ExcelUrlToContentTwoCtx 同 ExcelUrlToContentTwo，ctx 取消时中止请求
+func ExcelUrlToContentTwoCtx(ctx context.Context, url string) (word []ExcelResult, fileSuffix string, FileSize int, err error) {
 	var excelResult []ExcelResult
-	suffix, err := getSuffix(url)
+	suffix, _ := getSuffix(url)
+	filePath, err := saveFile(ctx, url, suffix)
 	if err != nil {
-		return excelResult, "", 0, errors.New("获取前缀失败！")
+		return excelResult, "", 0, errorx.Wrap(errorx.ErrDownload, err)
 	}
+	defer os.Remove(filePath)
 
-	filePath, err := saveFile(url, suffix)
+	t, err := detectType(filePath, url)
 	if err != nil {
-		return excelResult, "", 0, errors.New("文件保存在本地失败！")
+		return excelResult, "", 0, errorx.Wrap(errorx.ErrUnsupportedFormat, err)
 	}
 
 	size, err := countSize(filePath)
 	if err != nil {
-		return excelResult, "", 0, errors.New("计算文件大小失败！")
+		return excelResult, "", 0, errorx.Wrap(errorx.ErrRead, err)
 	}
 
-	xlFile, err := xlsx.OpenFile(filePath)
+	excelResult, err = readExcel(filePath, t)
 	if err != nil {
-		return excelResult, "", 0, errors.New("打开文件失败！")
-	}
-
-	defer os.Remove(filePath)
-
-	for _, sheet := range xlFile.Sheets {
-		list := ExcelResult{}
-		var table [][]string
-		for _, row := range sheet.Rows {
-			var rows []string
-			for _, cell := range row.Cells {
-				rows = append(rows, cell.String())
-			}
-			table = append(table, rows)
-		}
-
-		list.Content = table
-		list.Name = sheet.Name
-		excelResult = append(excelResult, list)
+		return excelResult, "", 0, err
 	}
 
-	return excelResult, suffix, size, nil
+	return excelResult, t.Ext, size, nil
 }
 
 // PdfToContent pdf文件转文字
 func PdfToContent(filePath string) (word string, fileSuffix string, FileSize int, err error) {
-	suffix, err := getSuffix(filePath)
+	suffix, err := detectSuffix(filePath, filePath)
 	if err != nil {
-		return "", "", 0, errors.New("获取前缀失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrUnsupportedFormat, err)
 	}
 	size, err := countSize(filePath)
 	if err != nil {
-		return "", "", 0, errors.New("计算文件大小失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrRead, err)
 	}
 
-	pdf.DebugOn = true
 	text, err := readPdf(filePath) // Read local pdf file
 	if err != nil {
-		return "", "", 0, errors.New("读取文件失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrParse, err)
 	}
 
 	return text, suffix, size, nil
@@ -253,39 +240,53 @@ func PdfToContent(filePath string) (word string, fileSuffix string, FileSize int
 
 // PdfUrlToContent pdf url文件转文字
 func PdfUrlToContent(url string) (word string, fileSuffix string, FileSize int, err error) {
-	suffix, err := getSuffix(url)
+	return PdfUrlToContentCtx(context.Background(), url)
+}
+
+// PdfUrlToContentCtx 同 PdfUrlToContent，ctx 取消时中止请求
+func PdfUrlToContentCtx(ctx context.Context, url string) (word string, fileSuffix string, FileSize int, err error) {
+	suffix, _ := getSuffix(url)
+	filePath, err := saveFile(ctx, url, suffix)
 	if err != nil {
-		return "", "", 0, errors.New("获取前缀失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrDownload, err)
 	}
+	defer os.Remove(filePath)
 
-	filePath, err := saveFile(url, suffix)
+	suffix, err = detectSuffix(filePath, url)
 	if err != nil {
-		return "", "", 0, errors.New("文件保存在本地失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrUnsupportedFormat, err)
 	}
 
 	size, err := countSize(filePath)
 	if err != nil {
-		return "", "", 0, errors.New("计算文件大小失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrRead, err)
 	}
 
-	defer os.Remove(filePath)
-
-	pdf.DebugOn = true
 	text, err := readPdf(filePath) // Read local pdf file
 	if err != nil {
-		return "", "", 0, errors.New("读取文件失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrParse, err)
 	}
 
 	return text, suffix, size, nil
 }
 
-func readPdf(path string) (string, error) {
-	f, r, err := pdf.Open(path)
-	// remember close file
-	defer f.Close()
+func readPdf(path string) (text string, err error) {
+	err = readLocalFile(path, func(r io.ReaderAt, size int64) error {
+		text, err = parsePdfText(r, size)
+		return err
+	})
+	return text, err
+}
+
+func parsePdfText(ra io.ReaderAt, size int64) (string, error) {
+	r, err := pdf.NewReader(ra, size)
 	if err != nil {
 		return "", err
 	}
+	return pdfPlainText(r)
+}
+
+func pdfPlainText(r *pdf.Reader) (string, error) {
 	var buf bytes.Buffer
 	b, err := r.GetPlainText()
 	if err != nil {
@@ -297,160 +298,128 @@ func readPdf(path string) (string, error) {
 
 // ppt文件转文字
 func PptToContent(filePath string) (word string, fileSuffix string, FileSize int, err error) {
-	suffix, err := getSuffix(filePath)
+	t, err := detectType(filePath, filePath)
 	if err != nil {
-		return "", "", 0, errors.New("获取前缀失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrUnsupportedFormat, err)
 	}
 	size, err := countSize(filePath)
 	if err != nil {
-		return "", "", 0, errors.New("计算文件大小失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrRead, err)
 	}
 
-	ppt, err := presentation.Open(filePath)
+	text, err := pptToData(filePath, t)
 	if err != nil {
-		return "", "", 0, errors.New("读取文件失败！")
-	}
-
-	var text string
-	for _, slide := range ppt.Slides() {
-		//所有的控件
-		for _, choice := range slide.X().CSld.SpTree.Choice {
-			if choice.Sp == nil {
-				continue
-			}
-			//一个文本框或一个控件
-			for _, sp := range choice.Sp {
-				if sp.TxBody == nil {
-					continue
-				}
-				//数据
-				for _, p := range sp.TxBody.P {
-					textrun := p.EG_TextRun
-					//所有的EG_TextRun中的数据组合起来是一段
-					for _, run := range textrun {
-						if run.R != nil {
-							text += run.R.T
-						}
-					}
-					if len(text) == 0 {
-						continue
-					}
-				}
-			}
-		}
+		return "", "", 0, err
 	}
 
-	return text, suffix, size, nil
+	return text, t.Ext, size, nil
 }
 
 // ppt地址文件转文字
 func PptUrlToContent(url string) (word string, fileSuffix string, FileSize int, err error) {
-	suffix, err := getSuffix(url)
+	return PptUrlToContentCtx(context.Background(), url)
+}
+
+// PptUrlToContentCtx 同 PptUrlToContent，ctx 取消时中止请求
+func PptUrlToContentCtx(ctx context.Context, url string) (word string, fileSuffix string, FileSize int, err error) {
+	suffix, _ := getSuffix(url)
+	filePath, err := saveFile(ctx, url, suffix)
 	if err != nil {
-		return "", "", 0, errors.New("获取前缀失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrDownload, err)
 	}
+	defer os.Remove(filePath)
 
-	filePath, err := saveFile(url, suffix)
+	t, err := detectType(filePath, url)
 	if err != nil {
-		return "", "", 0, errors.New("文件保存在本地失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrUnsupportedFormat, err)
 	}
 
 	size, err := countSize(filePath)
 	if err != nil {
-		return "", "", 0, errors.New("计算文件大小失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrRead, err)
 	}
 
-	ppt, err := presentation.Open(filePath)
+	text, err := pptToData(filePath, t)
 	if err != nil {
-		return "", "", 0, errors.New("读取文件失败！")
-	}
-
-	var text string
-	for _, slide := range ppt.Slides() {
-		//所有的控件
-		for _, choice := range slide.X().CSld.SpTree.Choice {
-			if choice.Sp == nil {
-				continue
-			}
-			//一个文本框或一个控件
-			for _, sp := range choice.Sp {
-				if sp.TxBody == nil {
-					continue
-				}
-				//数据
-				for _, p := range sp.TxBody.P {
-					textrun := p.EG_TextRun
-					//所有的EG_TextRun中的数据组合起来是一段
-					for _, run := range textrun {
-						if run.R != nil {
-							text += run.R.T
-						}
-					}
-					if len(text) == 0 {
-						continue
-					}
-				}
-			}
-		}
+		return "", "", 0, err
 	}
 
-	return text, suffix, size, nil
+	return text, t.Ext, size, nil
 }
 
 // txt文件转文字
 func TxtToContent(filePath string) (word string, fileSuffix string, FileSize int, err error) {
-	suffix, err := getSuffix(filePath)
+	word, _, fileSuffix, FileSize, err = TxtToContentWithEncoding(filePath)
+	return word, fileSuffix, FileSize, err
+}
+
+// TxtToContentWithEncoding 同 TxtToContent，同时返回识别出的原始编码，如 utf-8、gb18030、utf-16le
+func TxtToContentWithEncoding(filePath string) (word string, encoding string, fileSuffix string, FileSize int, err error) {
+	suffix, err := detectSuffix(filePath, filePath)
 	if err != nil {
-		return "", "", 0, errors.New("获取前缀失败！")
+		return "", "", "", 0, errorx.Wrap(errorx.ErrUnsupportedFormat, err)
 	}
 	size, err := countSize(filePath)
 	if err != nil {
-		return "", "", 0, errors.New("计算文件大小失败！")
+		return "", "", "", 0, errorx.Wrap(errorx.ErrRead, err)
 	}
-	content, err := ioutil.ReadFile(filePath)
+	err = readLocalFile(filePath, func(r io.ReaderAt, size int64) (err error) {
+		word, encoding, err = parseTxt(r, size)
+		return err
+	})
 	if err != nil {
-		log.Fatal(err)
+		return "", "", "", 0, err
 	}
-	text := string(content)
-	text = strings.Replace(text, "\n", " ", -1)
 
-	if !utf8.ValidString(text) {
-		return "", "", 0, errors.New("文件编码只能是UTF-8！")
-	}
+	return word, encoding, suffix, size, nil
+}
 
-	return text, suffix, size, nil
+// parseTxt 读取文本并转换为 utf-8，换行替换为空格，同时返回识别出的编码
+func parseTxt(r io.ReaderAt, size int64) (text string, encoding string, err error) {
+	content, err := io.ReadAll(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return "", "", errorx.Wrap(errorx.ErrRead, err)
+	}
+	text, encoding, err = charset.Decode(content)
+	if err != nil {
+		return "", "", err
+	}
+	text = strings.Replace(text, "\n", " ", -1)
+	return text, encoding, nil
 }
 
 // txt地址文件转文字
 func TxtUrlToContent(url string) (word string, fileSuffix string, FileSize int, err error) {
-	suffix, err := getSuffix(url)
+	return TxtUrlToContentCtx(context.Background(), url)
+}
+
+// TxtUrlToContentCtx 同 TxtUrlToContent，ctx 取消时中止请求
+func TxtUrlToContentCtx(ctx context.Context, url string) (word string, fileSuffix string, FileSize int, err error) {
+	suffix, _ := getSuffix(url)
+	filePath, err := saveFile(ctx, url, suffix)
 	if err != nil {
-		return "", "", 0, err
+		return "", "", 0, errorx.Wrap(errorx.ErrDownload, err)
 	}
+	defer os.Remove(filePath)
 
-	filePath, err := saveFile(url, suffix)
+	suffix, err = detectSuffix(filePath, url)
 	if err != nil {
-		return "", "", 0, errors.New("文件保存在本地失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrUnsupportedFormat, err)
 	}
 
 	size, err := countSize(filePath)
 	if err != nil {
-		return "", "", 0, errors.New("计算文件大小失败！")
+		return "", "", 0, errorx.Wrap(errorx.ErrRead, err)
 	}
 
-	defer os.Remove(filePath)
-
-	content, err := ioutil.ReadFile(filePath)
+	var text string
+	err = readLocalFile(filePath, func(r io.ReaderAt, size int64) (err error) {
+		text, _, err = parseTxt(r, size)
+		return err
+	})
 	if err != nil {
-		log.Fatal(err)
-	}
-	text := string(content)
-
-	if !utf8.ValidString(text) {
-		return "", "", 0, errors.New("文件编码只能是UTF-8！")
+		return "", "", 0, err
 	}
 
-	text = strings.Replace(text, "\n", " ", -1)
-
 	return text, suffix, size, nil
 }
@@ -0,0 +1,354 @@
+package office
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"unicode"
+
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
+	"github.com/ledongthuc/pdf"
+	"github.com/unidoc/unipdf/v3/core"
+	"github.com/unidoc/unipdf/v3/model"
+)
+
+const (
+	// pdfMaxImagePixels 位图解码时允许的最大像素分量数
+	pdfMaxImagePixels = 64 << 20
+	// pdfMaxImageBytes JPEG 图片流的最大字节数
+	pdfMaxImageBytes = 64 << 20
+)
+
+// PageImage 待识别的扫描页
+type PageImage struct {
+	// Page 页码，从 1 开始
+	Page int
+	// Data 页面中最大的一张图片，无法提取时为空，此时后端可按 PdfPath 与 Page 识别整页
+	Data []byte
+	Mime string
+	// PdfPath 原始 pdf 的本地路径
+	PdfPath string
+}
+
+// PageRecognizer 扫描页 OCR 后端
+type PageRecognizer interface {
+	RecognizePage(ctx context.Context, page PageImage) (string, error)
+}
+
+// PageRecognizerFunc 函数形式的 PageRecognizer
+type PageRecognizerFunc func(ctx context.Context, page PageImage) (string, error)
+
+// RecognizePage 实现 PageRecognizer
+func (f PageRecognizerFunc) RecognizePage(ctx context.Context, page PageImage) (string, error) {
+	return f(ctx, page)
+}
+
+// PdfOcrOptions 扫描件识别选项
+type PdfOcrOptions struct {
+	// MinChars 每页有效字符少于该值时视为扫描页，默认 10
+	MinChars int
+	// Layout 文本页按阅读顺序重排
+	Layout bool
+}
+
+// PdfToContentWithOcr pdf文件转文字，扫描页交给 recognizer 识别后按页序合并
+func PdfToContentWithOcr(ctx context.Context, filePath string, recognizer PageRecognizer, opts PdfOcrOptions) (word string, fileSuffix string, FileSize int, err error) {
+	suffix, err := detectSuffix(filePath, filePath)
+	if err != nil {
+		return "", "", 0, errorx.Wrap(errorx.ErrUnsupportedFormat, err)
+	}
+	size, err := countSize(filePath)
+	if err != nil {
+		return "", "", 0, errorx.Wrap(errorx.ErrRead, err)
+	}
+
+	pages, err := PdfPagesWithOcr(ctx, filePath, recognizer, opts)
+	if err != nil {
+		return "", "", 0, err
+	}
+	return joinPdfPages(pages), suffix, size, nil
+}
+
+// PdfUrlToContentWithOcr pdf url文件转文字，扫描页交给 recognizer 识别
+func PdfUrlToContentWithOcr(ctx context.Context, url string, recognizer PageRecognizer, opts PdfOcrOptions) (word string, fileSuffix string, FileSize int, err error) {
+	return withUrlFile(ctx, url, func(filePath string) (string, string, int, error) {
+		return PdfToContentWithOcr(ctx, filePath, recognizer, opts)
+	})
+}
+
+// PdfPagesWithOcr pdf文件按页转文字，只有扫描页才会调用 recognizer
+func PdfPagesWithOcr(ctx context.Context, filePath string, recognizer PageRecognizer, opts PdfOcrOptions) ([]PdfPage, error) {
+	if recognizer == nil {
+		return nil, errorx.ErrNotConfigured
+	}
+	minChars := opts.MinChars
+	if minChars <= 0 {
+		minChars = 10
+	}
+
+	var pages []PdfPage
+	err := readLocalFile(filePath, func(ra io.ReaderAt, size int64) error {
+		r, err := pdf.NewReader(ra, size)
+		if err != nil {
+			return errorx.Wrap(errorx.ErrRead, err)
+		}
+		if pages, err = pdfReaderPages(r, PdfOptions{Layout: opts.Layout}); err != nil {
+			return err
+		}
+
+		// 文本和图片共用同一个 Reader，整个文档只打开一次
+		extractor := &pdfImageExtractor{r: r, ra: ra, size: size}
+		for i, p := range pages {
+			if countChars(p.Text) >= minChars {
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			img := PageImage{Page: p.Number, PdfPath: filePath}
+			img.Data, img.Mime = extractor.pageImage(img.Page)
+
+			text, err := recognizer.RecognizePage(ctx, img)
+			if err != nil {
+				return fmt.Errorf("page %d: %w", img.Page, err)
+			}
+			pages[i].Text = text
+			pages[i].Ocr = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pages, nil
+}
+
+func joinPdfPages(pages []PdfPage) string {
+	var buf bytes.Buffer
+	for i, p := range pages {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString(p.Text)
+	}
+	return buf.String()
+}
+
+// countChars 统计非空白字符数
+func countChars(s string) int {
+	n := 0
+	for _, r := range s {
+		if !unicode.IsSpace(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// pdfImageExtractor 提取扫描页中的图片；ledongthuc/pdf 不支持 DCTDecode，JPEG 流改由 unipdf 读取
+type pdfImageExtractor struct {
+	r    *pdf.Reader
+	ra   io.ReaderAt
+	size int64
+	// raw 首次遇到 JPEG 时打开，为 nil 且 rawOpened 为 true 表示打开失败
+	raw       *model.PdfReader
+	rawOpened bool
+}
+
+// pageImage 返回页面中面积最大的图片，无法提取时返回空
+func (e *pdfImageExtractor) pageImage(num int) (data []byte, mime string) {
+	// 解析库遇到不支持的过滤器会直接 panic
+	defer func() {
+		if recover() != nil {
+			data, mime = nil, ""
+		}
+	}()
+
+	xobjects := e.r.Page(num).Resources().Key("XObject")
+	var (
+		best     pdf.Value
+		bestName string
+		area     int64
+	)
+	for _, name := range xobjects.Keys() {
+		v := xobjects.Key(name)
+		if v.Key("Subtype").Name() != "Image" {
+			continue
+		}
+		if a := v.Key("Width").Int64() * v.Key("Height").Int64(); a > area {
+			best, bestName, area = v, name, a
+		}
+	}
+	if area == 0 {
+		return nil, ""
+	}
+
+	switch pdfFilter(best) {
+	case "DCTDecode":
+		data = e.dctStream(num, bestName)
+		if data == nil {
+			return nil, ""
+		}
+		return data, "image/jpeg"
+	case "", "FlateDecode":
+		data = encodePdfImage(best)
+		if data == nil {
+			return nil, ""
+		}
+		return data, "image/png"
+	}
+	return nil, ""
+}
+
+// pdfFilter 返回图片流最后一个过滤器，JPEG 之前可能还套有其他编码
+func pdfFilter(v pdf.Value) string {
+	filter := v.Key("Filter")
+	if filter.Kind() == pdf.Array {
+		if filter.Len() == 0 {
+			return ""
+		}
+		return filter.Index(filter.Len() - 1).Name()
+	}
+	return filter.Name()
+}
+
+// dctStream 读取第 num 页名为 name 的 JPEG 图片流。unipdf 负责 xref、对象流和解密，
+// 只有单一 DCTDecode 过滤器时流中的原始数据就是 JPEG 文件；超过 pdfMaxImageBytes 或数据不是 JPEG 时返回 nil
+func (e *pdfImageExtractor) dctStream(num int, name string) []byte {
+	if !e.rawOpened {
+		e.rawOpened = true
+		e.raw = openRawPdf(e.ra, e.size)
+	}
+	if e.raw == nil {
+		return nil
+	}
+	page, err := e.raw.GetPage(num)
+	if err != nil || page.Resources == nil {
+		return nil
+	}
+	xobjects, ok := core.GetDict(page.Resources.XObject)
+	if !ok {
+		return nil
+	}
+	stream, ok := core.GetStream(xobjects.Get(core.PdfObjectName(name)))
+	if !ok {
+		return nil
+	}
+	filter := stream.Get("Filter")
+	if arr, ok := core.GetArray(filter); ok {
+		if arr.Len() != 1 {
+			return nil
+		}
+		filter = arr.Get(0)
+	}
+	if f, _ := core.GetNameVal(filter); f != "DCTDecode" {
+		return nil
+	}
+	data := stream.Stream
+	if len(data) < 4 || len(data) > pdfMaxImageBytes || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil
+	}
+	return data
+}
+
+// openRawPdf 打开 pdf，加密文件尝试用空密码解密
+func openRawPdf(ra io.ReaderAt, size int64) *model.PdfReader {
+	r, err := model.NewPdfReader(io.NewSectionReader(ra, 0, size))
+	if err != nil {
+		return nil
+	}
+	if encrypted, err := r.IsEncrypted(); err != nil {
+		return nil
+	} else if encrypted {
+		if ok, err := r.Decrypt([]byte("")); err != nil || !ok {
+			return nil
+		}
+	}
+	return r
+}
+
+// encodePdfImage 将 8 位灰度、RGB、CMYK 或 1 位黑白的位图编码为 png
+func encodePdfImage(v pdf.Value) []byte {
+	width, height := int(v.Key("Width").Int64()), int(v.Key("Height").Int64())
+	bpc := int(v.Key("BitsPerComponent").Int64())
+	comps := pdfColorComponents(v.Key("ColorSpace"))
+	if v.Key("ImageMask").Bool() {
+		comps, bpc = 1, 1
+	}
+	if width <= 0 || height <= 0 || comps == 0 || (bpc != 8 && !(bpc == 1 && comps == 1)) {
+		return nil
+	}
+	// 宽高来自文件，分配前限制像素数
+	if int64(width)*int64(height) > pdfMaxImagePixels/int64(comps) {
+		return nil
+	}
+
+	rowBytes := (width*comps*bpc + 7) / 8
+	rd := v.Reader()
+	defer rd.Close()
+	pix := make([]byte, rowBytes*height)
+	if _, err := io.ReadFull(rd, pix); err != nil {
+		return nil
+	}
+
+	rect := image.Rect(0, 0, width, height)
+	var img image.Image
+	switch {
+	case bpc == 1:
+		gray := image.NewGray(rect)
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				if pix[y*rowBytes+x/8]&(0x80>>(x%8)) != 0 {
+					gray.Pix[y*gray.Stride+x] = 0xFF
+				}
+			}
+		}
+		img = gray
+	case comps == 1:
+		img = &image.Gray{Pix: pix, Stride: rowBytes, Rect: rect}
+	case comps == 3:
+		rgba := image.NewRGBA(rect)
+		for i, j := 0, 0; i+2 < len(pix); i, j = i+3, j+4 {
+			rgba.Pix[j], rgba.Pix[j+1], rgba.Pix[j+2], rgba.Pix[j+3] = pix[i], pix[i+1], pix[i+2], 0xFF
+		}
+		img = rgba
+	case comps == 4:
+		cmyk := image.NewCMYK(rect)
+		copy(cmyk.Pix, pix)
+		img = cmyk
+	default:
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil
+	}
+	return buf.Bytes()
+}
+
+// pdfColorComponents 返回颜色空间的分量数，不支持的颜色空间返回 0
+func pdfColorComponents(cs pdf.Value) int {
+	switch cs.Kind() {
+	case pdf.Name:
+		switch cs.Name() {
+		case "DeviceGray", "CalGray":
+			return 1
+		case "DeviceRGB", "CalRGB":
+			return 3
+		case "DeviceCMYK":
+			return 4
+		}
+	case pdf.Array:
+		if cs.Len() > 1 && cs.Index(0).Name() == "ICCBased" {
+			return int(cs.Index(1).Key("N").Int64())
+		}
+		if cs.Len() > 0 {
+			return pdfColorComponents(cs.Index(0))
+		}
+	}
+	return 0
+}
@@ -0,0 +1,319 @@
+package office
+
+import (
+	"context"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
+	"github.com/ledongthuc/pdf"
+)
+
+// pdfMaxBuckets 分栏检测时横坐标最多划分的桶数
+const pdfMaxBuckets = 4096
+
+// PdfPage pdf 单页文本，Number 从 1 开始
+type PdfPage struct {
+	Number int    `json:"number"`
+	Text   string `json:"text"`
+	// Ocr 该页为扫描页，文本来自 OCR 识别
+	Ocr bool `json:"ocr"`
+}
+
+// PdfOptions pdf 按页读取选项
+type PdfOptions struct {
+	// Layout 根据字形坐标按行、分栏重排为阅读顺序
+	Layout bool
+	// FirstPage、LastPage 页码范围（含），为 0 表示不限制
+	FirstPage int
+	LastPage  int
+}
+
+// PdfPages pdf文件按页转文字
+func PdfPages(filePath string, opts PdfOptions) ([]PdfPage, error) {
+	return readPdfPages(filePath, opts)
+}
+
+// PdfUrlPages pdf url文件按页转文字
+func PdfUrlPages(url string, opts PdfOptions) ([]PdfPage, error) {
+	return PdfUrlPagesCtx(context.Background(), url, opts)
+}
+
+// PdfUrlPagesCtx 同 PdfUrlPages，ctx 取消时中止请求
+func PdfUrlPagesCtx(ctx context.Context, url string, opts PdfOptions) ([]PdfPage, error) {
+	suffix, _ := getSuffix(url)
+	filePath, err := saveFile(ctx, url, suffix)
+	if err != nil {
+		return nil, errorx.Wrap(errorx.ErrDownload, err)
+	}
+	defer os.Remove(filePath)
+
+	return readPdfPages(filePath, opts)
+}
+
+func readPdfPages(path string, opts PdfOptions) (pages []PdfPage, err error) {
+	err = readLocalFile(path, func(r io.ReaderAt, size int64) error {
+		pages, err = parsePdfPages(r, size, opts)
+		return err
+	})
+	return pages, err
+}
+
+func parsePdfPages(ra io.ReaderAt, size int64, opts PdfOptions) ([]PdfPage, error) {
+	r, err := pdf.NewReader(ra, size)
+	if err != nil {
+		return nil, errorx.Wrap(errorx.ErrRead, err)
+	}
+	return pdfReaderPages(r, opts)
+}
+
+func pdfReaderPages(r *pdf.Reader, opts PdfOptions) (pages []PdfPage, err error) {
+	// 解析库遇到损坏的内容流会直接 panic
+	defer func() {
+		if e := recover(); e != nil {
+			pages, err = nil, errorx.ErrParse
+		}
+	}()
+
+	first, last := opts.FirstPage, opts.LastPage
+	if first < 1 {
+		first = 1
+	}
+	if last < 1 || last > r.NumPage() {
+		last = r.NumPage()
+	}
+	if first > last {
+		return nil, errorx.ErrPageRange
+	}
+
+	fonts := make(map[string]*pdf.Font)
+	for i := first; i <= last; i++ {
+		p := r.Page(i)
+		if p.V.IsNull() {
+			pages = append(pages, PdfPage{Number: i})
+			continue
+		}
+		var text string
+		if opts.Layout {
+			text = layoutText(p.Content().Text)
+		} else {
+			for _, name := range p.Fonts() {
+				if _, ok := fonts[name]; !ok {
+					font := p.Font(name)
+					fonts[name] = &font
+				}
+			}
+			if text, err = p.GetPlainText(fonts); err != nil {
+				return nil, errorx.Wrap(errorx.ErrParse, err)
+			}
+		}
+		pages = append(pages, PdfPage{Number: i, Text: text})
+	}
+	return pages, nil
+}
+
+// pdfSegment 同一行中连续的一段文字
+type pdfSegment struct {
+	x0, x1 float64
+	text   string
+}
+
+type pdfLine struct {
+	segments []pdfSegment
+}
+
+// layoutText 按 y 坐标聚合成行，行内按 x 排序；若页面存在贯穿多行的空白竖带则视为分栏，逐栏输出
+func layoutText(glyphs []pdf.Text) string {
+	lines := groupLines(glyphs)
+	if len(lines) == 0 {
+		return ""
+	}
+	gutters := findGutters(lines)
+
+	var (
+		out     []string
+		columns [][]string
+	)
+	flush := func() {
+		for _, col := range columns {
+			out = append(out, col...)
+		}
+		columns = nil
+	}
+	for _, line := range lines {
+		cols, ok := splitColumns(line, gutters)
+		if !ok {
+			// 跨栏的行（如标题）结束当前分栏区域
+			flush()
+			out = append(out, joinSegments(line.segments))
+			continue
+		}
+		if columns == nil {
+			columns = make([][]string, len(gutters)+1)
+		}
+		for i, segs := range cols {
+			if len(segs) > 0 {
+				columns[i] = append(columns[i], joinSegments(segs))
+			}
+		}
+	}
+	flush()
+	return strings.Join(out, "\n")
+}
+
+func groupLines(glyphs []pdf.Text) []pdfLine {
+	sorted := make([]pdf.Text, 0, len(glyphs))
+	for _, g := range glyphs {
+		if g.S != "" {
+			sorted = append(sorted, g)
+		}
+	}
+	// pdf 坐标系 y 轴向上，自上而下即 y 从大到小
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Y > sorted[j].Y })
+
+	var (
+		lines []pdfLine
+		cur   []pdf.Text
+		curY  float64
+	)
+	for _, g := range sorted {
+		if len(cur) > 0 && math.Abs(g.Y-curY) > math.Max(g.FontSize, 1)*0.5 {
+			lines = append(lines, buildLine(cur))
+			cur = nil
+		}
+		if len(cur) == 0 {
+			curY = g.Y
+		}
+		cur = append(cur, g)
+	}
+	if len(cur) > 0 {
+		lines = append(lines, buildLine(cur))
+	}
+	return lines
+}
+
+// buildLine 字形间距超过字号两成时补空格，超过两个字号时拆为新的段
+func buildLine(glyphs []pdf.Text) pdfLine {
+	sort.SliceStable(glyphs, func(i, j int) bool { return glyphs[i].X < glyphs[j].X })
+	var line pdfLine
+	var (
+		seg strings.Builder
+		cur pdfSegment
+	)
+	for i, g := range glyphs {
+		if i > 0 {
+			gap := g.X - cur.x1
+			size := math.Max(g.FontSize, 1)
+			if gap > size*2 {
+				cur.text = seg.String()
+				line.segments = append(line.segments, cur)
+				seg.Reset()
+				cur = pdfSegment{x0: g.X}
+			} else if gap > size*0.2 && g.S != " " && !strings.HasSuffix(seg.String(), " ") {
+				seg.WriteString(" ")
+			}
+		} else {
+			cur.x0 = g.X
+		}
+		seg.WriteString(g.S)
+		if x1 := g.X + g.W; x1 > cur.x1 {
+			cur.x1 = x1
+		}
+	}
+	cur.text = seg.String()
+	line.segments = append(line.segments, cur)
+	return line
+}
+
+// findGutters 返回分栏间空白竖带的中线；多数行在该处均无文字才视为分栏
+func findGutters(lines []pdfLine) []float64 {
+	minX, maxX := math.Inf(1), math.Inf(-1)
+	for _, line := range lines {
+		for _, s := range line.segments {
+			minX = math.Min(minX, s.x0)
+			maxX = math.Max(maxX, s.x1)
+		}
+	}
+	span := maxX - minX
+	if !(span > 0) || math.IsInf(span, 0) || len(lines) < 2 {
+		return nil
+	}
+	// 按 step 分桶统计，常规页面每桶 1pt，异常的超大坐标不会放大 coverage
+	step := 1.0
+	if span > pdfMaxBuckets {
+		step = span / pdfMaxBuckets
+	}
+	width := int(span / step)
+
+	// 超过页面六成宽的段（标题、通栏段落）不参与判断
+	var segs []pdfSegment
+	for _, line := range lines {
+		for _, s := range line.segments {
+			if s.x1-s.x0 <= span*0.6 {
+				segs = append(segs, s)
+			}
+		}
+	}
+	coverage := make([]int, width+1)
+	for _, s := range segs {
+		for x := int((s.x0 - minX) / step); x <= int((s.x1-minX)/step) && x <= width; x++ {
+			coverage[x]++
+		}
+	}
+
+	// 允许少量段落越过空白带，空白带至少 10pt 宽，且右侧至少有两段文字
+	tolerance := len(segs) / 10
+	var gutters []float64
+	start := -1
+	for x := 0; x <= width; x++ {
+		if coverage[x] <= tolerance {
+			if start < 0 {
+				start = x
+			}
+			continue
+		}
+		if start > 0 && float64(x-start)*step >= 10 {
+			g := minX + float64(start+x)/2*step
+			right := 0
+			for _, s := range segs {
+				if s.x0 >= g {
+					right++
+				}
+			}
+			if right >= 2 {
+				gutters = append(gutters, g)
+			}
+		}
+		start = -1
+	}
+	return gutters
+}
+
+// splitColumns 将行内各段分到对应的栏，有段跨越分栏线时返回 false
+func splitColumns(line pdfLine, gutters []float64) ([][]pdfSegment, bool) {
+	cols := make([][]pdfSegment, len(gutters)+1)
+	for _, s := range line.segments {
+		col := 0
+		for i, g := range gutters {
+			if s.x0 < g && s.x1 > g {
+				return nil, false
+			}
+			if s.x0 >= g {
+				col = i + 1
+			}
+		}
+		cols[col] = append(cols[col], s)
+	}
+	return cols, true
+}
+
+func joinSegments(segs []pdfSegment) string {
+	texts := make([]string, 0, len(segs))
+	for _, s := range segs {
+		texts = append(texts, strings.TrimSpace(s.text))
+	}
+	return strings.Join(texts, "\t")
+}
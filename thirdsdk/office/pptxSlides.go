@@ -0,0 +1,886 @@
+package office
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
+)
+
+const (
+	nsPresentation = "http://schemas.openxmlformats.org/presentationml/2006/main"
+	nsDrawing      = "http://schemas.openxmlformats.org/drawingml/2006/main"
+	nsRelationship = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	nsDiagram      = "http://schemas.openxmlformats.org/drawingml/2006/diagram"
+)
+
+const (
+	// 排序时纵坐标相差不到一个行带（0.25 英寸）的形状视为同一行，按横坐标排列
+	pptxRowBand = 228600
+	// 没有标题占位符时，位于幻灯片上部这一比例内的短文本视为标题
+	pptxTitleArea = 4
+	// 推断为标题的文本最多字数
+	pptxTitleMaxLen = 50
+)
+
+// Slide 幻灯片结构
+type Slide struct {
+	Index  int     `json:"index"`            // 序号，从 1 开始
+	Title  string  `json:"title,omitempty"`  // 标题
+	Blocks []Block `json:"blocks"`           // 正文，按形状在幻灯片上的位置从上到下、从左到右排列
+	Notes  string  `json:"notes,omitempty"`  // 演讲者备注
+	Hidden bool    `json:"hidden,omitempty"` // 放映时隐藏
+}
+
+// Text 输出标题、正文和备注的纯文本，表格每行单元格以制表符分隔
+func (s *Slide) Text() string {
+	var lines []string
+	if s.Title != "" {
+		lines = append(lines, s.Title)
+	}
+	for _, b := range s.Blocks {
+		if text := blockText(b); text != "" {
+			lines = append(lines, text)
+		}
+	}
+	if s.Notes != "" {
+		lines = append(lines, s.Notes)
+	}
+	return strings.Join(lines, "\n")
+}
+
+type pptxParagraph struct {
+	text    string
+	level   int
+	bullet  bool
+	ordered bool
+}
+
+// pptxShape 形状中的占位符、位置和文本
+type pptxShape struct {
+	phType     string
+	phIdx      string
+	hasPh      bool
+	pos        *pptxPos
+	paragraphs []pptxParagraph
+}
+
+func (s *pptxShape) isTitle() bool {
+	return s.phType == "title" || s.phType == "ctrTitle"
+}
+
+// isBody 正文占位符，段落默认带项目符号
+func (s *pptxShape) isBody() bool {
+	return s.hasPh && (s.phType == "" || s.phType == "body" || s.phType == "obj")
+}
+
+// pptxPos 形状左上角在幻灯片上的坐标，单位为 EMU
+type pptxPos struct {
+	x, y float64
+}
+
+// pptxTransform 组合形状把子形状坐标映射到幻灯片坐标的仿射变换
+type pptxTransform struct {
+	ax, bx, ay, by float64
+}
+
+var identityTransform = pptxTransform{ax: 1, ay: 1}
+
+func (t pptxTransform) apply(p pptxPos) pptxPos {
+	return pptxPos{x: t.ax*p.x + t.bx, y: t.ay*p.y + t.by}
+}
+
+// then 返回先做 local 再做 t 的变换
+func (t pptxTransform) then(local pptxTransform) pptxTransform {
+	return pptxTransform{
+		ax: t.ax * local.ax, bx: t.ax*local.bx + t.bx,
+		ay: t.ay * local.ay, by: t.ay*local.by + t.by,
+	}
+}
+
+// pptxItem 幻灯片上的一个内容块
+type pptxItem struct {
+	pos    pptxPos
+	hasPos bool
+	title  bool
+	blocks []Block
+}
+
+// pptxPackage 解压后的 pptx 部件
+type pptxPackage struct {
+	parts map[string]*zip.File
+	// 幻灯片高度，未知时为 0
+	height float64
+	// 版式和母版中占位符的位置，按部件名缓存
+	placeholders map[string]map[string]pptxPos
+}
+
+func isP(n xml.Name, local string) bool {
+	return n.Local == local && n.Space == nsPresentation
+}
+
+func isA(n xml.Name, local string) bool {
+	return n.Local == local && n.Space == nsDrawing
+}
+
+// PptToSlides 读取 pptx 或 ppt 的幻灯片，包括标题、正文、表格和演讲者备注；
+// ppt 只读出文字，不区分标题和备注
+func PptToSlides(filePath string) (slides []Slide, fileSuffix string, FileSize int, err error) {
+	t, err := detectType(filePath, filePath)
+	if err != nil {
+		return nil, "", 0, errorx.Wrap(errorx.ErrUnsupportedFormat, err)
+	}
+	size, err := countSize(filePath)
+	if err != nil {
+		return nil, "", 0, errorx.Wrap(errorx.ErrRead, err)
+	}
+	err = readLocalFile(filePath, func(r io.ReaderAt, size int64) error {
+		slides, err = parseSlides(r, size, t)
+		return err
+	})
+	if err != nil {
+		return nil, "", 0, err
+	}
+	return slides, t.Ext, size, nil
+}
+
+// PptUrlToSlides 同 PptToSlides，读取 url 文件
+func PptUrlToSlides(url string) (slides []Slide, fileSuffix string, FileSize int, err error) {
+	return PptUrlToSlidesCtx(context.Background(), url)
+}
+
+// PptUrlToSlidesCtx 同 PptUrlToSlides，ctx 取消时中止请求
+func PptUrlToSlidesCtx(ctx context.Context, url string) (slides []Slide, fileSuffix string, FileSize int, err error) {
+	suffix, _ := getSuffix(url)
+	filePath, err := saveFile(ctx, url, suffix)
+	if err != nil {
+		return nil, "", 0, errorx.Wrap(errorx.ErrDownload, err)
+	}
+	defer os.Remove(filePath)
+
+	return PptToSlides(filePath)
+}
+
+// PptSlidesFromReader 同 PptToSlides，从 r 中读取，不落盘
+func PptSlidesFromReader(r io.ReaderAt, size int64) (slides []Slide, fileSuffix string, FileSize int, err error) {
+	t, err := readerType(r, size)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	slides, err = parseSlides(r, size, t)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	return slides, t.Ext, int(size), nil
+}
+
+// PptSlidesFromBytes 同 PptSlidesFromReader，读取内存中的文件
+func PptSlidesFromBytes(data []byte) (slides []Slide, fileSuffix string, FileSize int, err error) {
+	return PptSlidesFromReader(bytes.NewReader(data), int64(len(data)))
+}
+
+func parsePptxSlides(r io.ReaderAt, size int64) ([]Slide, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, errorx.Wrap(errorx.ErrRead, err)
+	}
+	pkg := &pptxPackage{
+		parts:        make(map[string]*zip.File, len(zr.File)),
+		placeholders: make(map[string]map[string]pptxPos),
+	}
+	for _, f := range zr.File {
+		pkg.parts[f.Name] = f
+	}
+
+	names, err := pptxSlideNames(pkg.parts)
+	if err != nil {
+		return nil, err
+	}
+	pkg.height = pptxSlideHeight(pkg.parts)
+	slides := make([]Slide, 0, len(names))
+	for _, name := range names {
+		if _, ok := pkg.parts[name]; !ok {
+			continue
+		}
+		slide, err := pkg.slide(name)
+		if err != nil {
+			return nil, errorx.Wrap(errorx.ErrParse, err)
+		}
+		slide.Index = len(slides) + 1
+		slides = append(slides, slide)
+	}
+	return slides, nil
+}
+
+func (pkg *pptxPackage) slide(name string) (Slide, error) {
+	var slide Slide
+	rels, err := parseRels(pkg.parts, name)
+	if err != nil {
+		return slide, err
+	}
+	layout := relTarget(rels, "/slideLayout")
+
+	var shapes []*pptxShape
+	var items []pptxItem
+	err = readZipXml(pkg.parts[name], func(d *xml.Decoder) error {
+		for {
+			tok, err := d.Token()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			se, ok := tok.(xml.StartElement)
+			if !ok {
+				continue
+			}
+			switch {
+			case isP(se.Name, "sld"):
+				slide.Hidden = attrVal(se, "show") == "0"
+			case isP(se.Name, "spTree"):
+				w := &pptxWalker{pkg: pkg, rels: rels}
+				if err := w.walkGroup(d, se.Name, identityTransform); err != nil {
+					return err
+				}
+				shapes, items = w.shapes, w.items
+			}
+		}
+	})
+	if err != nil {
+		return slide, err
+	}
+
+	// 占位符没有写位置时继承版式或母版中同一占位符的位置
+	for i := range items {
+		if !items[i].hasPos && shapes[i] != nil && shapes[i].hasPh {
+			if pos, ok := pkg.placeholderPos(layout, shapes[i]); ok {
+				items[i].pos, items[i].hasPos = pos, true
+			}
+		}
+	}
+	var visible []pptxItem
+	for _, item := range items {
+		if len(item.blocks) > 0 {
+			visible = append(visible, item)
+		}
+	}
+	items = visible
+	sort.SliceStable(items, func(i, j int) bool {
+		bi, bj := int64(items[i].pos.y)/pptxRowBand, int64(items[j].pos.y)/pptxRowBand
+		if bi != bj {
+			return bi < bj
+		}
+		return items[i].pos.x < items[j].pos.x
+	})
+
+	for _, item := range items {
+		if item.title && slide.Title == "" {
+			var texts []string
+			for _, b := range item.blocks {
+				texts = append(texts, b.Text)
+			}
+			slide.Title = strings.Join(texts, " ")
+			continue
+		}
+		slide.Blocks = append(slide.Blocks, item.blocks...)
+	}
+	if slide.Title == "" {
+		slide.Title = pkg.guessTitle(items)
+		if slide.Title != "" {
+			slide.Blocks = slide.Blocks[1:]
+		}
+	}
+
+	if notes := relTarget(rels, "/notesSlide"); notes != "" {
+		slide.Notes, err = pkg.notes(notes)
+	}
+	return slide, err
+}
+
+// guessTitle 没有标题占位符时，把位于幻灯片上部的第一个单段短文本作为标题
+func (pkg *pptxPackage) guessTitle(items []pptxItem) string {
+	if pkg.height <= 0 || len(items) == 0 {
+		return ""
+	}
+	first := items[0]
+	if !first.hasPos || first.pos.y > pkg.height/pptxTitleArea || len(first.blocks) != 1 {
+		return ""
+	}
+	b := first.blocks[0]
+	if b.Type != BlockParagraph || strings.Contains(b.Text, "\n") || utf8.RuneCountInString(b.Text) > pptxTitleMaxLen {
+		return ""
+	}
+	return b.Text
+}
+
+// notes 读取备注页中正文占位符的文字
+func (pkg *pptxPackage) notes(name string) (string, error) {
+	f, ok := pkg.parts[name]
+	if !ok {
+		return "", nil
+	}
+	var lines []string
+	err := readZipXml(f, func(d *xml.Decoder) error {
+		w := &pptxWalker{pkg: pkg}
+		if err := w.walk(d); err != nil {
+			return err
+		}
+		for _, shape := range w.shapes {
+			if shape == nil || shape.phType != "body" {
+				continue
+			}
+			for _, p := range shape.paragraphs {
+				lines = append(lines, p.text)
+			}
+		}
+		return nil
+	})
+	return strings.Join(lines, "\n"), err
+}
+
+// placeholderPos 先按 idx、再按类型在版式中查找占位符位置，找不到时查找母版
+func (pkg *pptxPackage) placeholderPos(layout string, shape *pptxShape) (pptxPos, bool) {
+	keys := []string{"type:" + placeholderType(shape.phType)}
+	if shape.phIdx != "" {
+		keys = append([]string{"idx:" + shape.phIdx}, keys...)
+	}
+
+	for _, part := range []string{layout, pkg.master(layout)} {
+		if part == "" {
+			continue
+		}
+		positions := pkg.layoutPlaceholders(part)
+		for _, key := range keys {
+			if pos, ok := positions[key]; ok {
+				return pos, true
+			}
+		}
+	}
+	return pptxPos{}, false
+}
+
+// placeholderType 统一占位符类型，未写类型的为正文，居中标题按标题处理
+func placeholderType(phType string) string {
+	switch phType {
+	case "", "obj":
+		return "body"
+	case "ctrTitle":
+		return "title"
+	}
+	return phType
+}
+
+func (pkg *pptxPackage) master(layout string) string {
+	if layout == "" {
+		return ""
+	}
+	rels, err := parseRels(pkg.parts, layout)
+	if err != nil {
+		return ""
+	}
+	return relTarget(rels, "/slideMaster")
+}
+
+// layoutPlaceholders 读取版式或母版中带位置的占位符
+func (pkg *pptxPackage) layoutPlaceholders(name string) map[string]pptxPos {
+	if positions, ok := pkg.placeholders[name]; ok {
+		return positions
+	}
+	positions := make(map[string]pptxPos)
+	pkg.placeholders[name] = positions
+	f, ok := pkg.parts[name]
+	if !ok {
+		return positions
+	}
+	_ = readZipXml(f, func(d *xml.Decoder) error {
+		w := &pptxWalker{pkg: pkg}
+		if err := w.walk(d); err != nil {
+			return err
+		}
+		for _, shape := range w.shapes {
+			if shape == nil || !shape.hasPh || shape.pos == nil {
+				continue
+			}
+			if shape.phIdx != "" {
+				positions["idx:"+shape.phIdx] = *shape.pos
+			}
+			key := "type:" + placeholderType(shape.phType)
+			if _, exists := positions[key]; !exists {
+				positions[key] = *shape.pos
+			}
+		}
+		return nil
+	})
+	return positions
+}
+
+// pptxWalker 遍历形状树，shapes 与 items 一一对应，表格和 SmartArt 对应的 shape 为空
+type pptxWalker struct {
+	pkg    *pptxPackage
+	rels   []relationship
+	shapes []*pptxShape
+	items  []pptxItem
+}
+
+// walk 遍历部件中的形状树
+func (w *pptxWalker) walk(d *xml.Decoder) error {
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if se, ok := tok.(xml.StartElement); ok && isP(se.Name, "spTree") {
+			return w.walkGroup(d, se.Name, identityTransform)
+		}
+	}
+}
+
+// walkGroup 遍历 spTree 或 grpSp 的子形状，直到 end 结束
+func (w *pptxWalker) walkGroup(d *xml.Decoder, end xml.Name, tf pptxTransform) error {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case isP(t.Name, "grpSpPr"):
+				local, err := parseGroupTransform(d)
+				if err != nil {
+					return err
+				}
+				tf = tf.then(local)
+			case isP(t.Name, "sp"):
+				shape, err := parsePptxShape(d)
+				if err != nil {
+					return err
+				}
+				w.addShape(shape, tf)
+			case isP(t.Name, "grpSp"):
+				if err := w.walkGroup(d, t.Name, tf); err != nil {
+					return err
+				}
+			case isP(t.Name, "graphicFrame"):
+				if err := w.parseFrame(d, tf); err != nil {
+					return err
+				}
+			case isP(t.Name, "pic"), isP(t.Name, "cxnSp"),
+				t.Name.Space == nsMarkup && t.Name.Local == "Fallback":
+				if err := d.Skip(); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			if t.Name == end {
+				return nil
+			}
+		}
+	}
+}
+
+func (w *pptxWalker) addShape(shape *pptxShape, tf pptxTransform) {
+	switch shape.phType {
+	case "sldNum", "dt", "ftr", "hdr", "sldImg":
+		// 页码、日期、页眉页脚和备注页中的幻灯片缩略图不算正文
+		w.shapes = append(w.shapes, shape)
+		w.items = append(w.items, pptxItem{})
+		return
+	}
+	item := pptxItem{title: shape.isTitle()}
+	if shape.pos != nil {
+		item.pos, item.hasPos = tf.apply(*shape.pos), true
+	}
+	for _, p := range shape.paragraphs {
+		item.blocks = append(item.blocks, paragraphBlock(p))
+	}
+	w.shapes = append(w.shapes, shape)
+	w.items = append(w.items, item)
+}
+
+func paragraphBlock(p pptxParagraph) Block {
+	if p.bullet {
+		return Block{Type: BlockListItem, Text: p.text, Level: p.level, Ordered: p.ordered}
+	}
+	return Block{Type: BlockParagraph, Text: p.text}
+}
+
+// parseFrame 读取 graphicFrame 中的表格和 SmartArt 文字，图表等其余内容忽略
+func (w *pptxWalker) parseFrame(d *xml.Decoder, tf pptxTransform) error {
+	item := pptxItem{}
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case isA(t.Name, "off") && !item.hasPos:
+				item.pos, item.hasPos = tf.apply(parsePptxPos(t)), true
+			case isA(t.Name, "tbl"):
+				table, err := parsePptxTable(d)
+				if err != nil {
+					return err
+				}
+				item.blocks = append(item.blocks, Block{Type: BlockTable, Table: table})
+			case t.Name.Space == nsDiagram && t.Name.Local == "relIds":
+				for _, a := range t.Attr {
+					if a.Name.Space == nsRelationship && a.Name.Local == "dm" {
+						item.blocks = append(item.blocks, w.diagramBlocks(relById(w.rels, a.Value))...)
+					}
+				}
+			}
+		case xml.EndElement:
+			if isP(t.Name, "graphicFrame") {
+				w.shapes = append(w.shapes, nil)
+				w.items = append(w.items, item)
+				return nil
+			}
+		}
+	}
+}
+
+// diagramBlocks 读取 SmartArt 数据部件中各节点的文字，每个节点一个列表项
+func (w *pptxWalker) diagramBlocks(name string) []Block {
+	f, ok := w.pkg.parts[name]
+	if !ok {
+		return nil
+	}
+	var blocks []Block
+	_ = readZipXml(f, func(d *xml.Decoder) error {
+		for {
+			tok, err := d.Token()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if se, ok := tok.(xml.StartElement); ok && isA(se.Name, "p") {
+				p, err := parsePptxParagraph(d, false)
+				if err != nil {
+					return err
+				}
+				if p.text != "" {
+					blocks = append(blocks, Block{Type: BlockListItem, Text: p.text})
+				}
+			}
+		}
+	})
+	return blocks
+}
+
+func parsePptxPos(se xml.StartElement) pptxPos {
+	x, _ := strconv.ParseFloat(attrVal(se, "x"), 64)
+	y, _ := strconv.ParseFloat(attrVal(se, "y"), 64)
+	return pptxPos{x: x, y: y}
+}
+
+// parseGroupTransform 读取组合形状的 off、ext、chOff、chExt，返回子坐标到父坐标的变换
+func parseGroupTransform(d *xml.Decoder) (pptxTransform, error) {
+	var off, ext, chOff, chExt pptxPos
+	hasExt := false
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return identityTransform, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case isA(t.Name, "off"):
+				off = parsePptxPos(t)
+			case isA(t.Name, "chOff"):
+				chOff = parsePptxPos(t)
+			case isA(t.Name, "ext"), isA(t.Name, "chExt"):
+				cx, _ := strconv.ParseFloat(attrVal(t, "cx"), 64)
+				cy, _ := strconv.ParseFloat(attrVal(t, "cy"), 64)
+				if t.Name.Local == "ext" {
+					ext = pptxPos{x: cx, y: cy}
+				} else {
+					chExt, hasExt = pptxPos{x: cx, y: cy}, true
+				}
+			}
+		case xml.EndElement:
+			if isP(t.Name, "grpSpPr") {
+				tf := pptxTransform{ax: 1, ay: 1}
+				if hasExt && chExt.x > 0 && chExt.y > 0 {
+					tf.ax, tf.ay = ext.x/chExt.x, ext.y/chExt.y
+				}
+				tf.bx, tf.by = off.x-chOff.x*tf.ax, off.y-chOff.y*tf.ay
+				return tf, nil
+			}
+		}
+	}
+}
+
+// pptxSlideNames 按 presentation.xml 中的顺序返回幻灯片部件名
+func pptxSlideNames(parts map[string]*zip.File) ([]string, error) {
+	rels, err := readRels(parts, "ppt/presentation.xml")
+	if err != nil {
+		return nil, errorx.Wrap(errorx.ErrRead, err)
+	}
+	pres, ok := parts["ppt/presentation.xml"]
+	if !ok {
+		return nil, errorx.ErrRead
+	}
+
+	var names []string
+	err = readZipXml(pres, func(d *xml.Decoder) error {
+		for {
+			tok, err := d.Token()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if se, ok := tok.(xml.StartElement); ok && isP(se.Name, "sldId") {
+				for _, a := range se.Attr {
+					if a.Name.Space == nsRelationship && a.Name.Local == "id" {
+						if target, ok := rels[a.Value]; ok {
+							names = append(names, target)
+						}
+					}
+				}
+			}
+		}
+	})
+	if err != nil {
+		return nil, errorx.Wrap(errorx.ErrParse, err)
+	}
+	return names, nil
+}
+
+// pptxSlideHeight 读取 presentation.xml 中的幻灯片高度，读取失败时返回 0
+func pptxSlideHeight(parts map[string]*zip.File) float64 {
+	pres, ok := parts["ppt/presentation.xml"]
+	if !ok {
+		return 0
+	}
+	var height float64
+	_ = readZipXml(pres, func(d *xml.Decoder) error {
+		for {
+			tok, err := d.Token()
+			if err != nil {
+				return err
+			}
+			if se, ok := tok.(xml.StartElement); ok && isP(se.Name, "sldSz") {
+				height, _ = strconv.ParseFloat(attrVal(se, "cy"), 64)
+				return nil
+			}
+		}
+	})
+	return height
+}
+
+// relationship 部件关系，Target 已解析为包内的完整部件名
+type relationship struct {
+	Id     string
+	Type   string
+	Target string
+}
+
+// readRels 读取部件的关系文件，返回关系 Id 到目标部件名的映射
+func readRels(parts map[string]*zip.File, part string) (map[string]string, error) {
+	list, err := parseRels(parts, part)
+	rels := make(map[string]string, len(list))
+	for _, rel := range list {
+		rels[rel.Id] = rel.Target
+	}
+	return rels, err
+}
+
+// parseRels 读取部件的关系文件，忽略外部链接
+func parseRels(parts map[string]*zip.File, part string) ([]relationship, error) {
+	dir, file := path.Split(part)
+	relsName := dir + "_rels/" + file + ".rels"
+	f, ok := parts[relsName]
+	if !ok {
+		return nil, nil
+	}
+	var rels []relationship
+	err := readZipXml(f, func(d *xml.Decoder) error {
+		for {
+			tok, err := d.Token()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			se, ok := tok.(xml.StartElement)
+			if !ok || se.Name.Local != "Relationship" || attrVal(se, "TargetMode") == "External" {
+				continue
+			}
+			target := attrVal(se, "Target")
+			if strings.HasPrefix(target, "/") {
+				target = strings.TrimPrefix(target, "/")
+			} else {
+				target = path.Join(dir, target)
+			}
+			rels = append(rels, relationship{Id: attrVal(se, "Id"), Type: attrVal(se, "Type"), Target: target})
+		}
+	})
+	return rels, err
+}
+
+// relTarget 返回第一个类型以 typeSuffix 结尾的关系目标
+func relTarget(rels []relationship, typeSuffix string) string {
+	for _, rel := range rels {
+		if strings.HasSuffix(rel.Type, typeSuffix) {
+			return rel.Target
+		}
+	}
+	return ""
+}
+
+func relById(rels []relationship, id string) string {
+	for _, rel := range rels {
+		if rel.Id == id {
+			return rel.Target
+		}
+	}
+	return ""
+}
+
+// parsePptxShape 读取 sp 形状，位置为 spPr 中的 off，没有时由占位符继承
+func parsePptxShape(d *xml.Decoder) (*pptxShape, error) {
+	shape := &pptxShape{}
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return shape, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case isP(t.Name, "ph"):
+				shape.hasPh = true
+				shape.phType = attrVal(t, "type")
+				shape.phIdx = attrVal(t, "idx")
+			case isA(t.Name, "off") && shape.pos == nil:
+				pos := parsePptxPos(t)
+				shape.pos = &pos
+			case isA(t.Name, "p"):
+				p, err := parsePptxParagraph(d, shape.isBody())
+				if err != nil {
+					return shape, err
+				}
+				if p.text != "" {
+					shape.paragraphs = append(shape.paragraphs, p)
+				}
+			}
+		case xml.EndElement:
+			if isP(t.Name, "sp") {
+				return shape, nil
+			}
+		}
+	}
+}
+
+func parsePptxParagraph(d *xml.Decoder, bullet bool) (pptxParagraph, error) {
+	p := pptxParagraph{bullet: bullet}
+	var text strings.Builder
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return p, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case isA(t.Name, "pPr"):
+				if lvl, err := strconv.Atoi(attrVal(t, "lvl")); err == nil {
+					p.level = lvl
+				}
+			case isA(t.Name, "buChar"):
+				p.bullet = true
+			case isA(t.Name, "buAutoNum"):
+				p.bullet, p.ordered = true, true
+			case isA(t.Name, "buNone"):
+				p.bullet = false
+			case isA(t.Name, "t"):
+				s, err := readCharData(d)
+				if err != nil {
+					return p, err
+				}
+				text.WriteString(s)
+			case isA(t.Name, "br"):
+				text.WriteString("\n")
+			}
+		case xml.EndElement:
+			if isA(t.Name, "p") {
+				p.text = strings.TrimSpace(text.String())
+				return p, nil
+			}
+		}
+	}
+}
+
+func parsePptxTable(d *xml.Decoder) (*Table, error) {
+	table := &Table{}
+	var (
+		cell  *TableCell
+		texts []string
+	)
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case isA(t.Name, "tr"):
+				table.Rows = append(table.Rows, TableRow{})
+			case isA(t.Name, "tc"):
+				cell = &TableCell{VMerge: attrVal(t, "vMerge") == "1"}
+				if n, err := strconv.Atoi(attrVal(t, "gridSpan")); err == nil && n > 1 {
+					cell.ColSpan = n
+				}
+				// 被横向合并的单元格在 GFM 表格中由 ColSpan 补齐
+				if attrVal(t, "hMerge") == "1" {
+					cell = nil
+					if err := d.Skip(); err != nil {
+						return nil, err
+					}
+				}
+				texts = nil
+			case isA(t.Name, "p"):
+				p, err := parsePptxParagraph(d, false)
+				if err != nil {
+					return nil, err
+				}
+				if p.text != "" {
+					texts = append(texts, p.text)
+				}
+			}
+		case xml.EndElement:
+			switch {
+			case isA(t.Name, "tc") && cell != nil:
+				cell.Text = strings.Join(texts, "\n")
+				if n := len(table.Rows); n > 0 {
+					table.Rows[n-1].Cells = append(table.Rows[n-1].Cells, *cell)
+				}
+				cell = nil
+			case isA(t.Name, "tbl"):
+				return table, nil
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package office
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
+	"github.com/comqositi/toolkits/thirdsdk/filetype"
+)
+
+// WordFromReader 同 WordToContent，从 r 中读取 docx 或 doc，不落盘
+func WordFromReader(r io.ReaderAt, size int64) (word string, fileSuffix string, FileSize int, err error) {
+	t, err := readerType(r, size)
+	if err != nil {
+		return "", "", 0, err
+	}
+	text, err := parseWordText(r, size, t, WordOptions{})
+	if err != nil {
+		return "", "", 0, err
+	}
+	return text, t.Ext, int(size), nil
+}
+
+// WordFromBytes 同 WordFromReader，读取内存中的文件
+func WordFromBytes(data []byte) (word string, fileSuffix string, FileSize int, err error) {
+	return WordFromReader(bytes.NewReader(data), int64(len(data)))
+}
+
+// ExcelFromReader 同 ExcelToContentTwo，从 r 中读取 xlsx 或 xls，不落盘
+func ExcelFromReader(r io.ReaderAt, size int64) (word []ExcelResult, fileSuffix string, FileSize int, err error) {
+	t, err := readerType(r, size)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	sheets, err := parseExcel(r, size, t)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	return sheets, t.Ext, int(size), nil
+}
+
+// ExcelFromBytes 同 ExcelFromReader，读取内存中的文件
+func ExcelFromBytes(data []byte) (word []ExcelResult, fileSuffix string, FileSize int, err error) {
+	return ExcelFromReader(bytes.NewReader(data), int64(len(data)))
+}
+
+// PptFromReader 同 PptToContent，从 r 中读取 pptx 或 ppt，不落盘
+func PptFromReader(r io.ReaderAt, size int64) (word string, fileSuffix string, FileSize int, err error) {
+	t, err := readerType(r, size)
+	if err != nil {
+		return "", "", 0, err
+	}
+	text, err := parsePptText(r, size, t)
+	if err != nil {
+		return "", "", 0, err
+	}
+	return text, t.Ext, int(size), nil
+}
+
+// PptFromBytes 同 PptFromReader，读取内存中的文件
+func PptFromBytes(data []byte) (word string, fileSuffix string, FileSize int, err error) {
+	return PptFromReader(bytes.NewReader(data), int64(len(data)))
+}
+
+// PdfFromReader 同 PdfToContent，从 r 中读取 pdf，不落盘
+func PdfFromReader(r io.ReaderAt, size int64) (word string, fileSuffix string, FileSize int, err error) {
+	t, err := readerType(r, size)
+	if err != nil {
+		return "", "", 0, err
+	}
+	text, err := parsePdfText(r, size)
+	if err != nil {
+		return "", "", 0, errorx.Wrap(errorx.ErrParse, err)
+	}
+	return text, t.Ext, int(size), nil
+}
+
+// PdfFromBytes 同 PdfFromReader，读取内存中的文件
+func PdfFromBytes(data []byte) (word string, fileSuffix string, FileSize int, err error) {
+	return PdfFromReader(bytes.NewReader(data), int64(len(data)))
+}
+
+// PdfPagesFromReader 同 PdfPages，从 r 中读取 pdf，不落盘
+func PdfPagesFromReader(r io.ReaderAt, size int64, opts PdfOptions) ([]PdfPage, error) {
+	return parsePdfPages(r, size, opts)
+}
+
+// TxtFromReader 同 TxtToContent，从 r 中读取文本，编码自动识别并转换为 utf-8
+func TxtFromReader(r io.ReaderAt, size int64) (word string, fileSuffix string, FileSize int, err error) {
+	word, _, fileSuffix, FileSize, err = TxtFromReaderWithEncoding(r, size)
+	return word, fileSuffix, FileSize, err
+}
+
+// TxtFromReaderWithEncoding 同 TxtFromReader，同时返回识别出的原始编码
+func TxtFromReaderWithEncoding(r io.ReaderAt, size int64) (word string, encoding string, fileSuffix string, FileSize int, err error) {
+	word, encoding, err = parseTxt(r, size)
+	if err != nil {
+		return "", "", "", 0, err
+	}
+	return word, encoding, filetype.Txt.Ext, int(size), nil
+}
+
+// TxtFromBytes 同 TxtFromReader，读取内存中的文本
+func TxtFromBytes(data []byte) (word string, fileSuffix string, FileSize int, err error) {
+	return TxtFromReader(bytes.NewReader(data), int64(len(data)))
+}
+
+// readerType 按内容识别文件格式
+func readerType(r io.ReaderAt, size int64) (filetype.Type, error) {
+	t, err := filetype.Detect(r, size)
+	if err != nil || t.IsUnknown() {
+		return filetype.Unknown, errorx.ErrUnsupportedFormat
+	}
+	return t, nil
+}
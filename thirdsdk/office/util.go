@@ -1,102 +1,256 @@
 package office
 
 import (
-	"crypto/md5"
-	"errors"
-	"fmt"
-	"github.com/nguyenthenguyen/docx"
+	"context"
+	"encoding/binary"
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
+	"github.com/comqositi/toolkits/thirdsdk/fetcher"
+	"github.com/comqositi/toolkits/thirdsdk/filetype"
+	"github.com/tealeg/xlsx"
 	"io"
-	"io/ioutil"
 	"net/http"
 	"os"
-	"regexp"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
+	"unicode/utf16"
+
+	"golang.org/x/text/encoding/charmap"
 )
 
-func saveFile(url string, suffix string) (string, error) {
-	byString, err := md5ByString(url)
-	if err != nil {
-		return "", err
-	}
-	targetName := "temporary" + byString + "." + suffix
+var urlFetcher atomic.Pointer[fetcher.Fetcher]
 
-	// 发起GET请求
-	response, err := http.Get(url)
-	if err != nil {
-		return "", err
+// SetHttpClient 设置远程文件下载使用的 http 客户端，传 nil 恢复默认；大小和地址限制使用默认值
+func SetHttpClient(c *http.Client) {
+	SetFetcher(fetcher.ForClient(c))
+}
+
+// SetFetcher 设置远程文件下载器，传 nil 恢复为 fetcher.Default()
+func SetFetcher(f *fetcher.Fetcher) {
+	urlFetcher.Store(f)
+}
+
+func loadFetcher() *fetcher.Fetcher {
+	if f := urlFetcher.Load(); f != nil {
+		return f
 	}
-	defer response.Body.Close()
+	return fetcher.Default()
+}
 
-	// 创建临时文件
-	tmpFile, err := ioutil.TempFile("/tmp", "tempfile")
+// saveFile 下载 url 到本地临时文件，suffix 为空时按响应中的文件名取后缀
+func saveFile(ctx context.Context, url string, suffix string) (string, error) {
+	f, err := loadFetcher().Fetch(ctx, url)
 	if err != nil {
 		return "", err
 	}
-	defer tmpFile.Close()
+	if suffix == "" || suffix == f.Ext() {
+		return f.Path, nil
+	}
 
-	// 将HTTP响应的内容写入临时文件
-	_, err = io.Copy(tmpFile, response.Body)
-	if err != nil {
-		return "", errors.New("写入临时文件时出错！")
+	targetName := strings.TrimSuffix(f.Path, filepath.Ext(f.Path)) + "." + suffix
+	if err := os.Rename(f.Path, targetName); err != nil {
+		f.Remove()
+		return "", errorx.Wrap(errorx.ErrTempFile, err)
 	}
+	return targetName, nil
+}
+
+func getSuffix(url string) (string, error) {
+	return filetype.ExtFromPath(url)
+}
 
-	// 重命名临时文件为目标文件名并保存到本地/tmp目录下
-	err = os.Rename(tmpFile.Name(), "/tmp/"+targetName)
+// detectSuffix 按文件内容识别规范后缀，纯文本或无法识别时使用 name 中的后缀
+func detectSuffix(filePath string, name string) (string, error) {
+	t, err := detectType(filePath, name)
 	if err != nil {
-		return "", errors.New("重命名临时文件失败！")
+		return "", err
 	}
-	return "/tmp/" + targetName, nil
+	return t.Ext, nil
 }
 
-func getSuffix(url string) (string, error) {
-	dotIndex := strings.LastIndex(url, ".")
-	if dotIndex == -1 || dotIndex == len(url)-1 {
-		return "", errors.New("没有文件后缀或者文件名以点号结尾！")
+func detectType(filePath string, name string) (filetype.Type, error) {
+	ext, _ := getSuffix(name)
+	if ext == "" {
+		// 远程文件地址中没有后缀时，下载时已按响应中的文件名命名
+		ext, _ = getSuffix(filePath)
 	}
-	suffix := url[dotIndex+1:]
-	return suffix, nil
+	t, err := filetype.DetectFile(filePath)
+	if err == nil && !t.IsUnknown() && (t != filetype.Txt || ext == "") {
+		return t, nil
+	}
+	if ext == "" {
+		return filetype.Unknown, filetype.ErrNoExt
+	}
+	if byExt := filetype.ByExt(ext); !byExt.IsUnknown() {
+		return byExt, nil
+	}
+	return filetype.Type{Ext: ext}, nil
 }
 
 func countSize(filePath string) (int, error) {
 	fileInfo, err := os.Stat(filePath)
 	if err != nil {
-		return 0, errors.New("计算文件大小失败！")
+		return 0, errorx.Wrap(errorx.ErrRead, err)
 	}
 
 	fileSize := int(fileInfo.Size())
 	return fileSize, nil
 }
 
-func md5ByString(str string) (string, error) {
-	m := md5.New()
-	_, err := io.WriteString(m, str)
+func wordToData(local string, t filetype.Type, opts WordOptions) (text string, err error) {
+	err = readLocalFile(local, func(r io.ReaderAt, size int64) error {
+		text, err = parseWordText(r, size, t, opts)
+		return err
+	})
+	return text, err
+}
+
+// parseWordText 读取 docx 或 doc 中的文字，t 为已识别的格式，doc 忽略 opts
+func parseWordText(r io.ReaderAt, size int64, t filetype.Type, opts WordOptions) (string, error) {
+	if t == filetype.Doc {
+		return readDocBinary(r, size)
+	}
+
+	doc, err := parseWordDocument(r, size, opts)
 	if err != nil {
 		return "", err
 	}
-	arr := m.Sum(nil)
-	return fmt.Sprintf("%x", arr), nil
+	return doc.Text(), nil
 }
 
-func wordToData(local string) (string, error) {
-	r, err := docx.ReadDocxFile(local)
+// readExcel 读取 xlsx 或 xls 文件中的所有工作表
+func readExcel(filePath string, t filetype.Type) (sheets []ExcelResult, err error) {
+	err = readLocalFile(filePath, func(r io.ReaderAt, size int64) error {
+		sheets, err = parseExcel(r, size, t)
+		return err
+	})
+	return sheets, err
+}
+
+func parseExcel(r io.ReaderAt, size int64, t filetype.Type) ([]ExcelResult, error) {
+	if t == filetype.Xls {
+		return readXlsBinary(r, size)
+	}
+
+	xlFile, err := xlsx.OpenReaderAt(r, size)
 	if err != nil {
-		return "", errors.New("读取文件失败！")
+		return nil, errorx.Wrap(errorx.ErrRead, err)
 	}
 
-	docx := r.Editable()
-	docx.Replace("old_2_1", "new_2_1", -1)
-	docx.Replace("old_2_2", "new_2_2", -1)
-	res := docx.GetContent()
-	regex := regexp.MustCompile(`<w:t>(.*?)</w:t>`)
-	// 查找所有匹配项
-	matches := regex.FindAllStringSubmatch(res, -1)
+	var excelResult []ExcelResult
+	for _, sheet := range xlFile.Sheets {
+		list := ExcelResult{}
+		var table [][]string
+		for _, row := range sheet.Rows {
+			var rows []string
+			for _, cell := range row.Cells {
+				rows = append(rows, cell.String())
+			}
+			table = append(table, rows)
+		}
 
-	resStr := ""
-	for _, match := range matches {
-		resStr += match[1]
+		list.Content = table
+		list.Name = sheet.Name
+		excelResult = append(excelResult, list)
 	}
+	return excelResult, nil
+}
+
+// excelToQa 每个工作表跳过首行表头，第一列为问题，第二列为答案
+func excelToQa(sheets []ExcelResult) []excelRes {
+	var list []excelRes
+	for _, sheet := range sheets {
+		for k, row := range sheet.Content {
+			if k == 0 {
+				continue
+			}
+			per := excelRes{}
+			if len(row) > 0 {
+				per.Question = row[0]
+			}
+			if len(row) > 1 {
+				per.Answer = row[1]
+			}
+			list = append(list, per)
+		}
+	}
+	return list
+}
 
-	defer r.Close()
+// pptToData 读取 pptx 或 ppt 文件中的文字
+func pptToData(filePath string, t filetype.Type) (text string, err error) {
+	err = readLocalFile(filePath, func(r io.ReaderAt, size int64) error {
+		text, err = parsePptText(r, size, t)
+		return err
+	})
+	return text, err
+}
 
-	return resStr, nil
+func parsePptText(r io.ReaderAt, size int64, t filetype.Type) (string, error) {
+	slides, err := parseSlides(r, size, t)
+	if err != nil {
+		return "", err
+	}
+	return slidesText(slides), nil
+}
+
+// parseSlides 读取 pptx 或 ppt 的幻灯片，ppt 不区分标题、备注和内容类型，每段文字为一个段落
+func parseSlides(r io.ReaderAt, size int64, t filetype.Type) ([]Slide, error) {
+	if t != filetype.Ppt {
+		return parsePptxSlides(r, size)
+	}
+	texts, err := readPptBinary(r, size)
+	if err != nil {
+		return nil, err
+	}
+	slides := make([]Slide, len(texts))
+	for i, slide := range texts {
+		slides[i].Index = i + 1
+		for _, text := range slide {
+			slides[i].Blocks = append(slides[i].Blocks, Block{Type: BlockParagraph, Text: text})
+		}
+	}
+	return slides, nil
+}
+
+// slidesText 幻灯片之间以空行分隔
+func slidesText(slides []Slide) string {
+	texts := make([]string, 0, len(slides))
+	for i := range slides {
+		if text := slides[i].Text(); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+// readLocalFile 以 io.ReaderAt 方式读取本地文件
+func readLocalFile(filePath string, fn func(r io.ReaderAt, size int64) error) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return errorx.Wrap(errorx.ErrRead, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return errorx.Wrap(errorx.ErrRead, err)
+	}
+	return fn(f, info.Size())
+}
+
+func decodeUtf16(b []byte) string {
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(b[i*2:])
+	}
+	return string(utf16.Decode(u))
+}
+
+// decodeCp1252 解码 Windows-1252 单字节文本
+func decodeCp1252(b []byte) string {
+	r := make([]rune, len(b))
+	for i, c := range b {
+		r[i] = charmap.Windows1252.DecodeByte(c)
+	}
+	return string(r)
 }
@@ -0,0 +1,727 @@
+package office
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/xml"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/comqositi/toolkits/thirdsdk/errorx"
+)
+
+// BlockType 文档块类型
+type BlockType string
+
+const (
+	BlockHeading   BlockType = "heading"   // 标题
+	BlockParagraph BlockType = "paragraph" // 段落
+	BlockListItem  BlockType = "list_item" // 列表项
+	BlockTable     BlockType = "table"     // 表格
+)
+
+// WordDocument word文档结构
+type WordDocument struct {
+	Blocks []Block    `json:"blocks"`
+	Parts  []WordPart `json:"parts,omitempty"` // 页眉、页脚、脚注、尾注和批注，按 WordOptions 读取
+	// Revisions 正文中的修订，只在 RevisionAnnotated 方式下列出
+	Revisions []Revision `json:"revisions,omitempty"`
+}
+
+// Block 文档块，Level 对标题为标题级别（从 1 开始），对列表项为缩进层级（从 0 开始）
+type Block struct {
+	Type    BlockType `json:"type"`
+	Text    string    `json:"text,omitempty"`
+	Level   int       `json:"level,omitempty"`
+	Ordered bool      `json:"ordered,omitempty"` // 是否有序列表
+	Table   *Table    `json:"table,omitempty"`
+}
+
+// Table 表格
+type Table struct {
+	Rows []TableRow `json:"rows"`
+}
+
+// TableRow 表格行
+type TableRow struct {
+	Cells []TableCell `json:"cells"`
+}
+
+// TableCell 单元格，ColSpan 为横向合并的列数，VMerge 表示被上方单元格纵向合并
+type TableCell struct {
+	Text    string `json:"text"`
+	ColSpan int    `json:"col_span,omitempty"`
+	VMerge  bool   `json:"v_merge,omitempty"`
+}
+
+// Text 按块输出纯文本，表格每行单元格以制表符分隔，正文以外的内容跟在正文之后
+func (d *WordDocument) Text() string {
+	var lines []string
+	for _, b := range d.Blocks {
+		lines = append(lines, blockText(b))
+	}
+	for _, part := range d.Parts {
+		for _, b := range part.Blocks {
+			lines = append(lines, blockText(b))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (t *Table) text() string {
+	rows := make([]string, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		cells := make([]string, 0, len(row.Cells))
+		for _, cell := range row.Cells {
+			cells = append(cells, cell.Text)
+		}
+		rows = append(rows, strings.Join(cells, "\t"))
+	}
+	return strings.Join(rows, "\n")
+}
+
+// WordToDocument word文件转结构化文档
+func WordToDocument(filePath string) (doc *WordDocument, fileSuffix string, FileSize int, err error) {
+	return WordToDocumentWithOptions(filePath, WordOptions{})
+}
+
+// WordToDocumentWithOptions 同 WordToDocument，按 opts 同时读取页眉、页脚、脚注、尾注和批注
+func WordToDocumentWithOptions(filePath string, opts WordOptions) (doc *WordDocument, fileSuffix string, FileSize int, err error) {
+	suffix, err := detectSuffix(filePath, filePath)
+	if err != nil {
+		return nil, "", 0, errorx.Wrap(errorx.ErrUnsupportedFormat, err)
+	}
+	size, err := countSize(filePath)
+	if err != nil {
+		return nil, "", 0, errorx.Wrap(errorx.ErrRead, err)
+	}
+
+	doc, err = readWordDocument(filePath, opts)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	return doc, suffix, size, nil
+}
+
+// WordUrlToDocument word地址文件转结构化文档
+func WordUrlToDocument(url string) (doc *WordDocument, fileSuffix string, FileSize int, err error) {
+	return WordUrlToDocumentCtx(context.Background(), url)
+}
+
+// WordUrlToDocumentCtx 同 WordUrlToDocument，ctx 取消时中止请求
+func WordUrlToDocumentCtx(ctx context.Context, url string) (doc *WordDocument, fileSuffix string, FileSize int, err error) {
+	return WordUrlToDocumentWithOptions(ctx, url, WordOptions{})
+}
+
+// WordUrlToDocumentWithOptions 同 WordToDocumentWithOptions，读取 url 文件，ctx 取消时中止请求
+func WordUrlToDocumentWithOptions(ctx context.Context, url string, opts WordOptions) (doc *WordDocument, fileSuffix string, FileSize int, err error) {
+	suffix, _ := getSuffix(url)
+	filePath, err := saveFile(ctx, url, suffix)
+	if err != nil {
+		return nil, "", 0, errorx.Wrap(errorx.ErrDownload, err)
+	}
+	defer os.Remove(filePath)
+
+	suffix, err = detectSuffix(filePath, url)
+	if err != nil {
+		return nil, "", 0, errorx.Wrap(errorx.ErrUnsupportedFormat, err)
+	}
+
+	size, err := countSize(filePath)
+	if err != nil {
+		return nil, "", 0, errorx.Wrap(errorx.ErrRead, err)
+	}
+
+	doc, err = readWordDocument(filePath, opts)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	return doc, suffix, size, nil
+}
+
+// WordDocumentFromReader 同 WordToDocumentWithOptions，从 r 中读取 docx，不落盘
+func WordDocumentFromReader(r io.ReaderAt, size int64, opts WordOptions) (doc *WordDocument, fileSuffix string, FileSize int, err error) {
+	t, err := readerType(r, size)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	doc, err = parseWordDocument(r, size, opts)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	return doc, t.Ext, int(size), nil
+}
+
+func readWordDocument(filePath string, opts WordOptions) (doc *WordDocument, err error) {
+	err = readLocalFile(filePath, func(r io.ReaderAt, size int64) error {
+		doc, err = parseWordDocument(r, size, opts)
+		return err
+	})
+	return doc, err
+}
+
+func parseWordDocument(r io.ReaderAt, size int64, opts WordOptions) (*WordDocument, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, errorx.Wrap(errorx.ErrRead, err)
+	}
+	parts := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		parts[f.Name] = f
+	}
+
+	main, ok := parts["word/document.xml"]
+	if !ok {
+		return nil, errorx.ErrRead
+	}
+
+	p := &wordParser{
+		styles:    make(map[string]*wordStyle),
+		numbering: make(map[string]map[int]bool),
+		mode:      opts.Revisions,
+	}
+	// 样式和编号定义缺失时按普通段落处理
+	if f, ok := parts["word/styles.xml"]; ok {
+		_ = readZipXml(f, p.parseStyles)
+	}
+	if f, ok := parts["word/numbering.xml"]; ok {
+		_ = readZipXml(f, p.parseNumbering)
+	}
+
+	doc := &WordDocument{}
+	err = readZipXml(main, func(d *xml.Decoder) error {
+		blocks, err := p.parseBlocks(d, "body")
+		doc.Blocks = blocks
+		return err
+	})
+	if err != nil {
+		return nil, errorx.Wrap(errorx.ErrParse, err)
+	}
+	if p.mode == RevisionAnnotated {
+		doc.Revisions = p.revisions
+	}
+	if opts.any() {
+		rels, _ := parseRels(parts, main.Name)
+		doc.Parts = p.readParts(parts, rels, opts, len(doc.Blocks))
+	}
+	return doc, nil
+}
+
+func readZipXml(f *zip.File, fn func(d *xml.Decoder) error) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return fn(xml.NewDecoder(rc))
+}
+
+const (
+	nsWordMain   = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+	nsWordStrict = "http://purl.oclc.org/ooxml/wordprocessingml/main"
+	nsMarkup     = "http://schemas.openxmlformats.org/markup-compatibility/2006"
+)
+
+var headingStyleRegex = regexp.MustCompile(`^(?:heading|标题)\s*([1-9])$`)
+
+type wordStyle struct {
+	basedOn    string
+	heading    int
+	numId      string
+	ilvl       int
+	hasNumbers bool
+}
+
+type wordParser struct {
+	styles    map[string]*wordStyle
+	numbering map[string]map[int]bool // numId -> ilvl -> 是否有序
+	// 正文中对页眉页脚、脚注、尾注和批注的引用
+	refs      []wordRef
+	sections  int
+	mode      RevisionMode
+	revisions []Revision
+}
+
+func isW(n xml.Name, local string) bool {
+	return n.Local == local && (n.Space == nsWordMain || n.Space == nsWordStrict)
+}
+
+func attrVal(se xml.StartElement, local string) string {
+	for _, a := range se.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func (p *wordParser) parseStyles(d *xml.Decoder) error {
+	var cur *wordStyle
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch {
+		case isW(se.Name, "style"):
+			cur = &wordStyle{}
+			p.styles[attrVal(se, "styleId")] = cur
+		case cur == nil:
+		case isW(se.Name, "name"):
+			name := strings.ToLower(attrVal(se, "val"))
+			if m := headingStyleRegex.FindStringSubmatch(name); m != nil {
+				cur.heading, _ = strconv.Atoi(m[1])
+			} else if name == "title" && cur.heading == 0 {
+				cur.heading = 1
+			}
+		case isW(se.Name, "basedOn"):
+			cur.basedOn = attrVal(se, "val")
+		case isW(se.Name, "outlineLvl"):
+			if lvl, err := strconv.Atoi(attrVal(se, "val")); err == nil && lvl < 9 && cur.heading == 0 {
+				cur.heading = lvl + 1
+			}
+		case isW(se.Name, "numId"):
+			cur.numId = attrVal(se, "val")
+			cur.hasNumbers = true
+		case isW(se.Name, "ilvl"):
+			cur.ilvl, _ = strconv.Atoi(attrVal(se, "val"))
+		}
+	}
+}
+
+func (p *wordParser) parseNumbering(d *xml.Decoder) error {
+	abstract := make(map[string]map[int]bool)
+	numToAbstract := make(map[string]string)
+	var (
+		curAbstract map[int]bool
+		curLvl      int
+		curNum      string
+	)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch {
+		case isW(se.Name, "abstractNum"):
+			curAbstract = make(map[int]bool)
+			abstract[attrVal(se, "abstractNumId")] = curAbstract
+		case isW(se.Name, "lvl"):
+			curLvl, _ = strconv.Atoi(attrVal(se, "ilvl"))
+		case isW(se.Name, "numFmt") && curAbstract != nil:
+			v := attrVal(se, "val")
+			curAbstract[curLvl] = v != "bullet" && v != "none"
+		case isW(se.Name, "num"):
+			curNum = attrVal(se, "numId")
+		case isW(se.Name, "abstractNumId") && curNum != "":
+			numToAbstract[curNum] = attrVal(se, "val")
+		}
+	}
+	for num, abs := range numToAbstract {
+		if lvls, ok := abstract[abs]; ok {
+			p.numbering[num] = lvls
+		}
+	}
+	return nil
+}
+
+// resolveStyle 沿 basedOn 链合并标题级别和编号
+func (p *wordParser) resolveStyle(id string) wordStyle {
+	var res wordStyle
+	for depth := 0; id != "" && depth < 16; depth++ {
+		s, ok := p.styles[id]
+		if !ok {
+			break
+		}
+		if res.heading == 0 {
+			res.heading = s.heading
+		}
+		if !res.hasNumbers && s.hasNumbers {
+			res.numId, res.ilvl, res.hasNumbers = s.numId, s.ilvl, true
+		}
+		id = s.basedOn
+	}
+	return res
+}
+
+// parseBlocks 读取段落和表格，直到遇到名为 end 的结束标签
+func (p *wordParser) parseBlocks(d *xml.Decoder, end string) ([]Block, error) {
+	var blocks []Block
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return blocks, nil
+		}
+		if err != nil {
+			return blocks, err
+		}
+		// 读取当前元素时记下的引用指向它的第一个块，节属性记录该节结束时的块数
+		at, start, revStart := len(blocks), len(p.refs), len(p.revisions)
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case isW(t.Name, "p"):
+				bs, err := p.parseParagraph(d)
+				if err != nil {
+					return blocks, err
+				}
+				blocks = append(blocks, bs...)
+			case isW(t.Name, "tbl"):
+				table, err := p.parseTable(d)
+				if err != nil {
+					return blocks, err
+				}
+				blocks = append(blocks, Block{Type: BlockTable, Table: table})
+			case isW(t.Name, "sectPr"):
+				if err := p.parseSection(d); err != nil {
+					return blocks, err
+				}
+			case isW(t.Name, "commentRangeStart"):
+				p.addRef(PartComment, attrVal(t, "id"))
+			case t.Name.Space == nsMarkup && t.Name.Local == "Fallback":
+				if err := d.Skip(); err != nil {
+					return blocks, err
+				}
+			}
+		case xml.EndElement:
+			if isW(t.Name, end) {
+				return blocks, nil
+			}
+		}
+		for i := start; i < len(p.refs); i++ {
+			if p.refs[i].section > 0 {
+				p.refs[i].block = len(blocks)
+			} else {
+				p.refs[i].block = at
+			}
+		}
+		for i := revStart; i < len(p.revisions); i++ {
+			p.revisions[i].Block = at
+		}
+	}
+}
+
+// parseParagraph 解析段落，文本框中嵌套的段落和表格跟在当前段落之后返回
+func (p *wordParser) parseParagraph(d *xml.Decoder) ([]Block, error) {
+	var (
+		text    strings.Builder
+		nested  []Block
+		styleId string
+		numId   string
+		ilvl    = -1
+		outline = -1
+		// revs 嵌套的修订，如他人删除了插入的文字，外层在前
+		revs []*wordRevision
+	)
+	// write 按修订方式写入文字，任一层修订不保留时丢弃；标注方式下写入最内层修订，在修订结束时统一输出
+	write := func(s string) {
+		for _, rev := range revs {
+			if !p.mode.keep(rev.Type) {
+				return
+			}
+		}
+		if p.mode == RevisionAnnotated && len(revs) > 0 {
+			revs[len(revs)-1].text.WriteString(s)
+			return
+		}
+		text.WriteString(s)
+	}
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case isW(t.Name, "pPr"):
+				if err := p.parseParagraphProps(d, &styleId, &numId, &ilvl, &outline); err != nil {
+					return nil, err
+				}
+			case isW(t.Name, "t"), isW(t.Name, "delText"):
+				s, err := readCharData(d)
+				if err != nil {
+					return nil, err
+				}
+				write(s)
+			case isW(t.Name, "tab"):
+				write("\t")
+			case isW(t.Name, "br"), isW(t.Name, "cr"):
+				write("\n")
+			case revisionType(t.Name) != "":
+				revs = append(revs, &wordRevision{Revision: Revision{
+					Type:   revisionType(t.Name),
+					Author: attrVal(t, "author"),
+					Date:   parseOoxmlDate(attrVal(t, "date")),
+				}})
+			case isW(t.Name, "footnoteReference"):
+				p.addRef(PartFootnote, attrVal(t, "id"))
+			case isW(t.Name, "endnoteReference"):
+				p.addRef(PartEndnote, attrVal(t, "id"))
+			case isW(t.Name, "commentRangeStart"), isW(t.Name, "commentReference"):
+				p.addRef(PartComment, attrVal(t, "id"))
+			case isW(t.Name, "instrText"):
+				if err := d.Skip(); err != nil {
+					return nil, err
+				}
+			case isW(t.Name, "p"):
+				bs, err := p.parseParagraph(d)
+				if err != nil {
+					return nil, err
+				}
+				nested = append(nested, bs...)
+			case isW(t.Name, "tbl"):
+				table, err := p.parseTable(d)
+				if err != nil {
+					return nil, err
+				}
+				nested = append(nested, Block{Type: BlockTable, Table: table})
+			case t.Name.Space == nsMarkup && t.Name.Local == "Fallback":
+				if err := d.Skip(); err != nil {
+					return nil, err
+				}
+			}
+		case xml.EndElement:
+			if len(revs) > 0 && revisionType(t.Name) != "" {
+				// 内层修订带标注的文字写入外层修订
+				rev := revs[len(revs)-1]
+				revs = revs[:len(revs)-1]
+				if len(revs) > 0 {
+					p.endRevision(rev, &revs[len(revs)-1].text)
+				} else {
+					p.endRevision(rev, &text)
+				}
+			}
+			if !isW(t.Name, "p") {
+				continue
+			}
+			s := strings.TrimSpace(text.String())
+			if s == "" {
+				return nested, nil
+			}
+			block := p.classify(s, styleId, numId, ilvl, outline)
+			return append([]Block{block}, nested...), nil
+		}
+	}
+}
+
+func (p *wordParser) classify(text, styleId, numId string, ilvl, outline int) Block {
+	style := p.resolveStyle(styleId)
+	heading := style.heading
+	if outline >= 0 && outline < 9 {
+		heading = outline + 1
+	}
+	if heading > 0 {
+		return Block{Type: BlockHeading, Text: text, Level: heading}
+	}
+
+	if numId == "" && style.hasNumbers {
+		numId = style.numId
+		if ilvl < 0 {
+			ilvl = style.ilvl
+		}
+	}
+	if numId != "" && numId != "0" {
+		if ilvl < 0 {
+			ilvl = 0
+		}
+		return Block{Type: BlockListItem, Text: text, Level: ilvl, Ordered: p.numbering[numId][ilvl]}
+	}
+	return Block{Type: BlockParagraph, Text: text}
+}
+
+func (p *wordParser) parseParagraphProps(d *xml.Decoder, styleId, numId *string, ilvl, outline *int) error {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case isW(t.Name, "pStyle"):
+				*styleId = attrVal(t, "val")
+			case isW(t.Name, "numId"):
+				*numId = attrVal(t, "val")
+			case isW(t.Name, "ilvl"):
+				*ilvl, _ = strconv.Atoi(attrVal(t, "val"))
+			case isW(t.Name, "outlineLvl"):
+				*outline, _ = strconv.Atoi(attrVal(t, "val"))
+			case isW(t.Name, "sectPr"):
+				// 段落属性中的节属性表示该段落是一节的最后一段
+				if err := p.parseSection(d); err != nil {
+					return err
+				}
+			case isW(t.Name, "pPrChange"), isW(t.Name, "rPr"):
+				// 修订前的段落属性和段落标记的字符属性
+				if err := d.Skip(); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			if isW(t.Name, "pPr") {
+				return nil
+			}
+		}
+	}
+}
+
+func (p *wordParser) parseTable(d *xml.Decoder) (*Table, error) {
+	table := &Table{}
+	// 当前行整行插入或删除
+	var rowRev RevisionType
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case isW(t.Name, "tr"):
+				table.Rows = append(table.Rows, TableRow{})
+				rowRev = ""
+			case isW(t.Name, "trPr"):
+				var err error
+				if rowRev, err = parseRowProps(d); err != nil {
+					return nil, err
+				}
+			case isW(t.Name, "tc"):
+				cell, err := p.parseCell(d)
+				if err != nil {
+					return nil, err
+				}
+				if n := len(table.Rows); n > 0 {
+					table.Rows[n-1].Cells = append(table.Rows[n-1].Cells, cell)
+				}
+			case isW(t.Name, "tblPr"), isW(t.Name, "tblGrid"):
+				if err := d.Skip(); err != nil {
+					return nil, err
+				}
+			}
+		case xml.EndElement:
+			switch {
+			case isW(t.Name, "tr"):
+				if n := len(table.Rows); n > 0 && rowRev != "" && !p.mode.keep(rowRev) {
+					table.Rows = table.Rows[:n-1]
+				}
+			case isW(t.Name, "tbl"):
+				return table, nil
+			}
+		}
+	}
+}
+
+// parseRowProps 读取行属性中的整行插入或删除标记
+func parseRowProps(d *xml.Decoder) (RevisionType, error) {
+	var res RevisionType
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return res, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if isW(t.Name, "ins") || isW(t.Name, "del") {
+				res = revisionType(t.Name)
+			}
+		case xml.EndElement:
+			if isW(t.Name, "trPr") {
+				return res, nil
+			}
+		}
+	}
+}
+
+func (p *wordParser) parseCell(d *xml.Decoder) (TableCell, error) {
+	var (
+		cell  TableCell
+		texts []string
+	)
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return cell, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case isW(t.Name, "gridSpan"):
+				if n, err := strconv.Atoi(attrVal(t, "val")); err == nil && n > 1 {
+					cell.ColSpan = n
+				}
+			case isW(t.Name, "vMerge"):
+				// 只有续接的单元格没有 val 或 val 为 continue
+				if v := attrVal(t, "val"); v == "" || v == "continue" {
+					cell.VMerge = true
+				}
+			case isW(t.Name, "p"):
+				bs, err := p.parseParagraph(d)
+				if err != nil {
+					return cell, err
+				}
+				for _, b := range bs {
+					texts = append(texts, blockText(b))
+				}
+			case isW(t.Name, "tbl"):
+				table, err := p.parseTable(d)
+				if err != nil {
+					return cell, err
+				}
+				texts = append(texts, table.text())
+			}
+		case xml.EndElement:
+			if isW(t.Name, "tc") {
+				cell.Text = strings.Join(texts, "\n")
+				return cell, nil
+			}
+		}
+	}
+}
+
+func blockText(b Block) string {
+	if b.Type == BlockTable {
+		return b.Table.text()
+	}
+	return b.Text
+}
+
+// readCharData 读取元素内的文本直到其结束标签
+func readCharData(d *xml.Decoder) (string, error) {
+	var sb strings.Builder
+	depth := 1
+	for depth > 0 {
+		tok, err := d.Token()
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			sb.Write(t)
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+	}
+	return sb.String(), nil
+}
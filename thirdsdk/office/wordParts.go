@@ -0,0 +1,223 @@
+package office
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"sort"
+)
+
+// PartType word 正文以外内容的来源
+type PartType string
+
+const (
+	PartHeader   PartType = "header"   // 页眉
+	PartFooter   PartType = "footer"   // 页脚
+	PartFootnote PartType = "footnote" // 脚注
+	PartEndnote  PartType = "endnote"  // 尾注
+	PartComment  PartType = "comment"  // 批注
+)
+
+// WordOptions 读取 docx 的选项，默认只读正文并接受所有修订
+type WordOptions struct {
+	Headers   bool         // 读取页眉
+	Footers   bool         // 读取页脚
+	Footnotes bool         // 读取脚注
+	Endnotes  bool         // 读取尾注
+	Comments  bool         // 读取批注
+	Revisions RevisionMode // 修订的输出方式
+}
+
+// any 是否需要读取正文以外的部件
+func (o WordOptions) any() bool {
+	return o.Headers || o.Footers || o.Footnotes || o.Endnotes || o.Comments
+}
+
+// WordPart 页眉、页脚、脚注、尾注或批注。Block 为锚定的正文块下标：
+// 脚注、尾注和批注为引用所在的块，页眉页脚为所在节的第一个块，正文中找不到引用时为 -1
+type WordPart struct {
+	Type   PartType `json:"type"`
+	Name   string   `json:"name"`             // 所在部件，如 word/footnotes.xml
+	Id     string   `json:"id,omitempty"`     // 脚注、尾注和批注的编号
+	Author string   `json:"author,omitempty"` // 批注作者
+	Block  int      `json:"block"`
+	Blocks []Block  `json:"blocks"`
+}
+
+// wordRef 正文中的引用，页眉页脚的 id 为关系 Id，section 为所在节的序号（从 1 开始）
+type wordRef struct {
+	kind    PartType
+	id      string
+	block   int
+	section int
+}
+
+func (p *wordParser) addRef(kind PartType, id string) {
+	p.refs = append(p.refs, wordRef{kind: kind, id: id})
+}
+
+// parseSection 读取节属性中的页眉页脚引用
+func (p *wordParser) parseSection(d *xml.Decoder) error {
+	p.sections++
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case isW(t.Name, "headerReference"):
+				p.refs = append(p.refs, wordRef{kind: PartHeader, id: attrVal(t, "id"), section: p.sections})
+			case isW(t.Name, "footerReference"):
+				p.refs = append(p.refs, wordRef{kind: PartFooter, id: attrVal(t, "id"), section: p.sections})
+			case isW(t.Name, "sectPrChange"):
+				// 修订前的节属性
+				if err := d.Skip(); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			if isW(t.Name, "sectPr") {
+				return nil
+			}
+		}
+	}
+}
+
+// anchors 计算每个引用锚定的块，页眉页脚按关系 Id 记录，其余按类型和编号记录首次引用的位置
+func (p *wordParser) anchors(blockCount int) map[wordRef]int {
+	// 各节结束时的块数，下一节从这里开始
+	sectionStart := map[int]int{1: 0}
+	for _, ref := range p.refs {
+		if ref.section > 0 {
+			sectionStart[ref.section+1] = ref.block
+		}
+	}
+	res := make(map[wordRef]int)
+	for _, ref := range p.refs {
+		block := ref.block
+		if ref.section > 0 {
+			block = sectionStart[ref.section]
+		}
+		if block >= blockCount {
+			block = blockCount - 1
+		}
+		key := wordRef{kind: ref.kind, id: ref.id}
+		if _, ok := res[key]; !ok {
+			res[key] = block
+		}
+	}
+	return res
+}
+
+// readParts 读取 opts 选中的部件，部件缺失或格式错误时跳过，结果按锚定位置排序
+func (p *wordParser) readParts(parts map[string]*zip.File, rels []relationship, opts WordOptions, blockCount int) []WordPart {
+	anchors := p.anchors(blockCount)
+	anchor := func(kind PartType, id string) int {
+		if block, ok := anchors[wordRef{kind: kind, id: id}]; ok {
+			return block
+		}
+		return -1
+	}
+
+	var res []WordPart
+	// 页眉页脚按正文中的引用顺序读取，同一部件被多个节引用时只读一次
+	seen := make(map[string]bool)
+	for _, ref := range p.refs {
+		if ref.section == 0 || (ref.kind == PartHeader && !opts.Headers) || (ref.kind == PartFooter && !opts.Footers) {
+			continue
+		}
+		name := relById(rels, ref.id)
+		f, ok := parts[name]
+		if !ok || seen[name] {
+			continue
+		}
+		seen[name] = true
+		end := "hdr"
+		if ref.kind == PartFooter {
+			end = "ftr"
+		}
+		var blocks []Block
+		err := readZipXml(f, func(d *xml.Decoder) (err error) {
+			blocks, err = p.parseBlocks(d, end)
+			return err
+		})
+		if err != nil || len(blocks) == 0 {
+			continue
+		}
+		res = append(res, WordPart{Type: ref.kind, Name: name, Block: anchor(ref.kind, ref.id), Blocks: blocks})
+	}
+
+	notes := []struct {
+		kind    PartType
+		enabled bool
+		relType string
+		elem    string
+	}{
+		{PartFootnote, opts.Footnotes, "/footnotes", "footnote"},
+		{PartEndnote, opts.Endnotes, "/endnotes", "endnote"},
+		{PartComment, opts.Comments, "/comments", "comment"},
+	}
+	for _, n := range notes {
+		if !n.enabled {
+			continue
+		}
+		name := relTarget(rels, n.relType)
+		f, ok := parts[name]
+		if !ok {
+			continue
+		}
+		var items []WordPart
+		_ = readZipXml(f, func(d *xml.Decoder) (err error) {
+			items, err = p.parseNotes(d, n.elem)
+			return err
+		})
+		for _, item := range items {
+			item.Type, item.Name, item.Block = n.kind, name, anchor(n.kind, item.Id)
+			res = append(res, item)
+		}
+	}
+
+	// 未被引用的排在最后
+	sort.SliceStable(res, func(i, j int) bool {
+		bi, bj := res[i].Block, res[j].Block
+		if bi < 0 || bj < 0 {
+			return bj < 0 && bi >= 0
+		}
+		return bi < bj
+	})
+	return res
+}
+
+// parseNotes 读取 footnotes.xml、endnotes.xml 或 comments.xml 中的每一条，跳过分隔符和空内容
+func (p *wordParser) parseNotes(d *xml.Decoder, elem string) ([]WordPart, error) {
+	var res []WordPart
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return res, nil
+		}
+		if err != nil {
+			return res, err
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok || !isW(se.Name, elem) {
+			continue
+		}
+		// 脚注和尾注的 type 为 separator 等时是分隔线
+		if attrVal(se, "type") != "" {
+			if err := d.Skip(); err != nil {
+				return res, err
+			}
+			continue
+		}
+		blocks, err := p.parseBlocks(d, elem)
+		if err != nil {
+			return res, err
+		}
+		if len(blocks) > 0 {
+			res = append(res, WordPart{Id: attrVal(se, "id"), Author: attrVal(se, "author"), Blocks: blocks})
+		}
+	}
+}
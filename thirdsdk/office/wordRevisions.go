@@ -0,0 +1,94 @@
+package office
+
+import (
+	"encoding/xml"
+	"strings"
+	"time"
+)
+
+// RevisionMode word 修订（跟踪更改）的输出方式
+type RevisionMode string
+
+const (
+	RevisionAccepted  RevisionMode = ""          // 接受所有修订：保留插入，去掉删除，默认值
+	RevisionOriginal  RevisionMode = "original"  // 修订前的原文：去掉插入，保留删除
+	RevisionAnnotated RevisionMode = "annotated" // 标注修订：插入写作 {++文字++}，删除写作 {--文字--}，其后以 {>>作者 时间<<} 注明
+)
+
+// RevisionType 修订类型
+type RevisionType string
+
+const (
+	RevisionInsert RevisionType = "insert" // 插入，包括移动后的位置
+	RevisionDelete RevisionType = "delete" // 删除，包括移动前的位置
+)
+
+// Revision 正文中的一处修订，Block 为所在正文块的下标
+type Revision struct {
+	Type   RevisionType `json:"type"`
+	Author string       `json:"author,omitempty"`
+	Date   *time.Time   `json:"date,omitempty"`
+	Text   string       `json:"text"`
+	Block  int          `json:"block"`
+}
+
+// wordRevision 正在读取的 ins、del、moveTo 或 moveFrom 元素
+type wordRevision struct {
+	Revision
+	text strings.Builder
+}
+
+// revisionType 返回修订元素的类型，不是修订元素时返回空串
+func revisionType(n xml.Name) RevisionType {
+	if n.Space != nsWordMain && n.Space != nsWordStrict {
+		return ""
+	}
+	switch n.Local {
+	case "ins", "moveTo":
+		return RevisionInsert
+	case "del", "moveFrom":
+		return RevisionDelete
+	}
+	return ""
+}
+
+// keep 按修订方式判断修订中的文字是否输出
+func (m RevisionMode) keep(t RevisionType) bool {
+	switch m {
+	case RevisionOriginal:
+		return t == RevisionDelete
+	case RevisionAnnotated:
+		return true
+	}
+	return t == RevisionInsert
+}
+
+// endRevision 修订结束，标注方式下写入带标注的文字并记录修订
+func (p *wordParser) endRevision(rev *wordRevision, text *strings.Builder) {
+	if p.mode != RevisionAnnotated || rev.text.Len() == 0 {
+		return
+	}
+	rev.Text = rev.text.String()
+	text.WriteString(rev.annotate())
+	p.revisions = append(p.revisions, rev.Revision)
+}
+
+// annotate 输出标注修订方式下的一处修订
+func (r *Revision) annotate() string {
+	mark := "++"
+	if r.Type == RevisionDelete {
+		mark = "--"
+	}
+	s := "{" + mark + r.Text + mark + "}"
+	var note []string
+	if r.Author != "" {
+		note = append(note, r.Author)
+	}
+	if r.Date != nil {
+		note = append(note, r.Date.Format(time.RFC3339))
+	}
+	if len(note) > 0 {
+		s += "{>>" + strings.Join(note, " ") + "<<}"
+	}
+	return s
+}
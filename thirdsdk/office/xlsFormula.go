@@ -0,0 +1,313 @@
+package office
+
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/tealeg/xlsx"
+)
+
+// xls 公式以逆波兰顺序的解析后记号（Ptg）保存，这里只还原常见的运算符、常量、引用和函数，
+// 名称、数组、共享公式和外部引用等无法还原时返回 false
+
+// xlsFunc 内置函数，argc 为 -1 表示参数个数可变，只能出现在 PtgFuncVar 中
+type xlsFunc struct {
+	name string
+	argc int
+}
+
+var xlsFuncs = map[uint16]xlsFunc{
+	0: {"COUNT", -1}, 1: {"IF", -1}, 2: {"ISNA", 1}, 3: {"ISERROR", 1}, 4: {"SUM", -1},
+	5: {"AVERAGE", -1}, 6: {"MIN", -1}, 7: {"MAX", -1}, 8: {"ROW", -1}, 9: {"COLUMN", -1},
+	10: {"NA", 0}, 12: {"STDEV", -1}, 15: {"SIN", 1}, 16: {"COS", 1}, 17: {"TAN", 1},
+	18: {"ATAN", 1}, 19: {"PI", 0}, 20: {"SQRT", 1}, 21: {"EXP", 1}, 22: {"LN", 1},
+	23: {"LOG10", 1}, 24: {"ABS", 1}, 25: {"INT", 1}, 26: {"SIGN", 1}, 27: {"ROUND", 2},
+	28: {"LOOKUP", -1}, 29: {"INDEX", -1}, 30: {"REPT", 2}, 31: {"MID", 3}, 32: {"LEN", 1},
+	33: {"VALUE", 1}, 34: {"TRUE", 0}, 35: {"FALSE", 0}, 36: {"AND", -1}, 37: {"OR", -1},
+	38: {"NOT", 1}, 39: {"MOD", 2}, 48: {"TEXT", 2}, 65: {"DATE", 3}, 66: {"TIME", 3},
+	67: {"DAY", 1}, 68: {"MONTH", 1}, 69: {"YEAR", 1}, 70: {"WEEKDAY", -1}, 71: {"HOUR", 1},
+	72: {"MINUTE", 1}, 73: {"SECOND", 1}, 74: {"NOW", 0}, 76: {"ROWS", 1}, 77: {"COLUMNS", 1},
+	78: {"OFFSET", -1}, 82: {"SEARCH", -1}, 97: {"ATAN2", 2}, 98: {"ASIN", 1}, 99: {"ACOS", 1},
+	100: {"CHOOSE", -1}, 101: {"HLOOKUP", -1}, 102: {"VLOOKUP", -1}, 109: {"LOG", -1},
+	111: {"CHAR", 1}, 112: {"LOWER", 1}, 113: {"UPPER", 1}, 114: {"PROPER", 1},
+	115: {"LEFT", -1}, 116: {"RIGHT", -1}, 117: {"EXACT", 2}, 118: {"TRIM", 1},
+	119: {"REPLACE", 4}, 120: {"SUBSTITUTE", -1}, 121: {"CODE", 1}, 124: {"FIND", -1},
+	126: {"ISERR", 1}, 127: {"ISTEXT", 1}, 128: {"ISNUMBER", 1}, 129: {"ISBLANK", 1},
+	130: {"T", 1}, 131: {"N", 1}, 140: {"DATEVALUE", 1}, 141: {"TIMEVALUE", 1},
+	148: {"INDIRECT", -1}, 162: {"CLEAN", 1}, 169: {"COUNTA", -1}, 183: {"PRODUCT", -1},
+	184: {"FACT", 1}, 197: {"TRUNC", -1}, 198: {"ISLOGICAL", 1}, 212: {"ROUNDUP", 2},
+	213: {"ROUNDDOWN", 2}, 221: {"TODAY", 0}, 227: {"MEDIAN", -1}, 228: {"SUMPRODUCT", -1},
+	336: {"CONCATENATE", -1}, 337: {"POWER", 2}, 344: {"SUBTOTAL", -1}, 345: {"SUMIF", -1},
+	346: {"COUNTIF", 2}, 347: {"COUNTBLANK", 1},
+}
+
+var xlsBinaryOps = map[byte]string{
+	0x03: "+", 0x04: "-", 0x05: "*", 0x06: "/", 0x07: "^", 0x08: "&",
+	0x09: "<", 0x0A: "<=", 0x0B: "=", 0x0C: ">=", 0x0D: ">", 0x0E: "<>",
+	0x0F: " ", 0x10: ",", 0x11: ":",
+}
+
+var xlsErrors = map[byte]string{
+	0x00: "#NULL!", 0x07: "#DIV/0!", 0x0F: "#VALUE!", 0x17: "#REF!",
+	0x1D: "#NAME?", 0x24: "#NUM!", 0x2A: "#N/A",
+}
+
+// xlsXti EXTERNSHEET 中的一项，指向 SUPBOOK 中的工作表范围
+type xlsXti struct {
+	supBook     int
+	first, last int
+}
+
+// formula 读取 FORMULA 记录中的公式，返回带等号的文本
+func (b *xlsBook) formula(data []byte) string {
+	if len(data) < 22 {
+		return ""
+	}
+	n := int(binary.LittleEndian.Uint16(data[20:]))
+	if 22+n > len(data) {
+		return ""
+	}
+	text, ok := b.decodeFormula(data[22 : 22+n])
+	if !ok {
+		return ""
+	}
+	return "=" + text
+}
+
+func (b *xlsBook) decodeFormula(rgce []byte) (string, bool) {
+	var stack []string
+	pop := func(n int) ([]string, bool) {
+		if n > len(stack) {
+			return nil, false
+		}
+		args := append([]string(nil), stack[len(stack)-n:]...)
+		stack = stack[:len(stack)-n]
+		return args, true
+	}
+
+	for i := 0; i < len(rgce); {
+		ptg := rgce[i]
+		i++
+		if ptg >= 0x80 {
+			return "", false
+		}
+		// 引用类记号的高位表示值、引用或数组类别，不影响文本
+		base := ptg
+		if ptg >= 0x20 {
+			base = ptg&0x1F | 0x20
+		}
+		need := func(n int) bool { return i+n <= len(rgce) }
+
+		if op, ok := xlsBinaryOps[ptg]; ok {
+			args, ok := pop(2)
+			if !ok {
+				return "", false
+			}
+			stack = append(stack, args[0]+op+args[1])
+			continue
+		}
+		switch base {
+		case 0x12, 0x13, 0x14, 0x15:
+			args, ok := pop(1)
+			if !ok {
+				return "", false
+			}
+			switch ptg {
+			case 0x12:
+				stack = append(stack, "+"+args[0])
+			case 0x13:
+				stack = append(stack, "-"+args[0])
+			case 0x14:
+				stack = append(stack, args[0]+"%")
+			default:
+				stack = append(stack, "("+args[0]+")")
+			}
+		case 0x16:
+			stack = append(stack, "")
+		case 0x17:
+			if !need(2) {
+				return "", false
+			}
+			s, n := readBiffShortString(rgce[i:])
+			i += 2 + n
+			stack = append(stack, `"`+strings.ReplaceAll(s, `"`, `""`)+`"`)
+		case 0x19:
+			if !need(3) {
+				return "", false
+			}
+			grbit, data := rgce[i], int(binary.LittleEndian.Uint16(rgce[i+1:]))
+			i += 3
+			switch {
+			case grbit&0x04 != 0:
+				// CHOOSE 的跳转表
+				i += (data + 1) * 2
+			case grbit&0x10 != 0:
+				args, ok := pop(1)
+				if !ok {
+					return "", false
+				}
+				stack = append(stack, "SUM("+args[0]+")")
+			}
+		case 0x1C:
+			if !need(1) {
+				return "", false
+			}
+			e, ok := xlsErrors[rgce[i]]
+			if !ok {
+				return "", false
+			}
+			i++
+			stack = append(stack, e)
+		case 0x1D:
+			if !need(1) {
+				return "", false
+			}
+			stack = append(stack, strings.ToUpper(strconv.FormatBool(rgce[i] != 0)))
+			i++
+		case 0x1E:
+			if !need(2) {
+				return "", false
+			}
+			stack = append(stack, strconv.Itoa(int(binary.LittleEndian.Uint16(rgce[i:]))))
+			i += 2
+		case 0x1F:
+			if !need(8) {
+				return "", false
+			}
+			v := math.Float64frombits(binary.LittleEndian.Uint64(rgce[i:]))
+			stack = append(stack, strconv.FormatFloat(v, 'f', -1, 64))
+			i += 8
+		case 0x21, 0x22:
+			argc := -1
+			if base == 0x22 {
+				if !need(1) {
+					return "", false
+				}
+				argc = int(rgce[i] & 0x7F)
+				i++
+			}
+			if !need(2) {
+				return "", false
+			}
+			fn, ok := xlsFuncs[binary.LittleEndian.Uint16(rgce[i:])&0x7FFF]
+			i += 2
+			if !ok {
+				return "", false
+			}
+			if argc < 0 {
+				if argc = fn.argc; argc < 0 {
+					return "", false
+				}
+			}
+			args, ok := pop(argc)
+			if !ok {
+				return "", false
+			}
+			stack = append(stack, fn.name+"("+strings.Join(args, ",")+")")
+		case 0x24:
+			if !need(4) {
+				return "", false
+			}
+			stack = append(stack, xlsCellRef(rgce[i:]))
+			i += 4
+		case 0x25:
+			if !need(8) {
+				return "", false
+			}
+			stack = append(stack, xlsAreaRef(rgce[i:]))
+			i += 8
+		case 0x26, 0x27, 0x28, 0x29:
+			// Mem 系列只标记随后子表达式的范围，跳过即可
+			n := 6
+			if base == 0x29 {
+				n = 2
+			}
+			if !need(n) {
+				return "", false
+			}
+			i += n
+		case 0x2A, 0x2B:
+			n := 4
+			if base == 0x2B {
+				n = 8
+			}
+			if !need(n) {
+				return "", false
+			}
+			stack = append(stack, "#REF!")
+			i += n
+		case 0x3A, 0x3B:
+			n := 6
+			if base == 0x3B {
+				n = 10
+			}
+			if !need(n) {
+				return "", false
+			}
+			sheet, ok := b.sheetRef(int(binary.LittleEndian.Uint16(rgce[i:])))
+			if !ok {
+				return "", false
+			}
+			if base == 0x3A {
+				stack = append(stack, sheet+xlsCellRef(rgce[i+2:]))
+			} else {
+				stack = append(stack, sheet+xlsAreaRef(rgce[i+2:]))
+			}
+			i += n
+		default:
+			return "", false
+		}
+	}
+	if len(stack) != 1 {
+		return "", false
+	}
+	return stack[0], true
+}
+
+// xlsCellRef 读取 (rw, col)，col 的最高两位分别表示行、列为相对引用
+func xlsCellRef(b []byte) string {
+	row := int(binary.LittleEndian.Uint16(b))
+	col := binary.LittleEndian.Uint16(b[2:])
+	return xlsx.GetCellIDStringFromCoordsWithFixed(int(col&0x3FFF), row, col&0x4000 == 0, col&0x8000 == 0)
+}
+
+// xlsAreaRef 读取 (rwFirst, rwLast, colFirst, colLast)
+func xlsAreaRef(b []byte) string {
+	first := []byte{b[0], b[1], b[4], b[5]}
+	last := []byte{b[2], b[3], b[6], b[7]}
+	return xlsCellRef(first) + ":" + xlsCellRef(last)
+}
+
+// sheetRef 按 EXTERNSHEET 下标返回带感叹号的工作表名，只支持本工作簿中的表
+func (b *xlsBook) sheetRef(ixti int) (string, bool) {
+	if ixti >= len(b.xti) {
+		return "", false
+	}
+	x := b.xti[ixti]
+	if x.supBook >= len(b.supBooks) || !b.supBooks[x.supBook] ||
+		x.first < 0 || x.last < x.first || x.last >= len(b.sheetNames) {
+		return "", false
+	}
+	name := quoteSheetName(b.sheetNames[x.first])
+	if x.last != x.first {
+		name = quoteSheetName(b.sheetNames[x.first] + ":" + b.sheetNames[x.last])
+	}
+	return name + "!", true
+}
+
+// quoteSheetName 名称含字母、数字、下划线和点号以外的字符时加单引号
+func quoteSheetName(name string) string {
+	plain := name != ""
+	for i, r := range name {
+		if !(r == '_' || r == '.' || r == ':' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' && i > 0 || r > 0x7F) {
+			plain = false
+			break
+		}
+	}
+	if plain {
+		return name
+	}
+	return "'" + strings.ReplaceAll(name, "'", "''") + "'"
+}